@@ -2,14 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/redis/go-redis/v9"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/config"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/database"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
 	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
 	redistrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/redis/go-redis.v9"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -20,24 +29,74 @@ var logger *slog.Logger
 
 func init() {
 	// Create JSON handler for structured logging
+	// PII is redacted by the policy installed by SetupRedaction once config is loaded
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level:       slog.LevelInfo,
+		ReplaceAttr: redact.ReplaceAttr,
 	})
 	logger = slog.New(handler)
 }
 
 func main() {
+	// Subcommands: `api migrate ...` and `api seed ...`; no arguments starts the server
+	// All cleanup is deferred inside run functions, so os.Exit never skips it
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "migrate":
+		err = runMigrate(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "seed":
+		err = runSeed(os.Args[2:])
+	default:
+		err = run()
+	}
+	if err != nil {
+		logger.Error("Application exited with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the API and blocks until SIGINT/SIGTERM, then shuts down in order:
+//  1. stop accepting connections and drain in-flight requests (App.ShutdownTimeout)
+//  2. stop the local cache subscription, close Redis and MySQL (unless STORAGE=memory)
+//  3. flush tracer, profiler and StatsD so the last traces of a deploy are sent
+func run() error {
+	// Load configuration from defaults, optional CONFIG_FILE and environment
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	// Secrets such as MYSQL_PASSWORD are redacted by Config.LogValue
+	logger.Info("Loaded configuration", "config", cfg)
+
+	if err := SetupRedaction(cfg); err != nil {
+		return err
+	}
+
 	// Start Datadog tracer (APM - 分散トレーシング)
 	// tracer.Start()により、dd-trace-goライブラリがDatadog Agent（デフォルトでlocalhost:8126）に接続
 	// span.Finish()が呼ばれた時に自動的にtrace-idとspan情報をDatadog Agentに送信
 	// 用途: リクエストの流れを追跡（Handler → UseCase → Repository）
 	tracer.Start(
-		tracer.WithEnv(os.Getenv("DD_ENV")),
-		tracer.WithService(os.Getenv("DD_SERVICE")),
-		tracer.WithServiceVersion(os.Getenv("DD_VERSION")),
+		tracer.WithEnv(cfg.Datadog.Env),
+		tracer.WithService(cfg.Datadog.Service),
+		tracer.WithServiceVersion(cfg.Datadog.Version),
 		tracer.WithLogStartup(true),
 	)
-	defer tracer.Stop()
+
+	// Telemetry is flushed last (deferred first), after requests have drained
+	// and data stores are closed, so their spans and metrics are not lost
+	var statsdClient *statsd.Client
+	profilerStarted := false
+	defer func() {
+		logger.Info("Flushing telemetry")
+		tracer.Stop()
+		if profilerStarted {
+			profiler.Stop()
+		}
+		if statsdClient != nil {
+			statsdClient.Close()
+		}
+	}()
 
 	// Start Datadog profiler (継続的プロファイリング)
 	// 用途: コードレベルのパフォーマンス分析（CPU使用率、メモリ割り当て）
@@ -55,10 +114,10 @@ func main() {
 	//    - Profile Type: "Allocated Memory" を選択
 	//    - Flame Graphで Self Allocated (関数自体の割り当て), Total Allocated (子関数含む)
 	// 4. 例: userRepo.Create()が遅い → db.ExecContext()が60%のCPUを消費していることが判明
-	err := profiler.Start(
-		profiler.WithService(os.Getenv("DD_SERVICE")),
-		profiler.WithEnv(os.Getenv("DD_ENV")),
-		profiler.WithVersion(os.Getenv("DD_VERSION")),
+	err = profiler.Start(
+		profiler.WithService(cfg.Datadog.Service),
+		profiler.WithEnv(cfg.Datadog.Env),
+		profiler.WithVersion(cfg.Datadog.Version),
 		profiler.WithProfileTypes(
 			profiler.CPUProfile,
 			profiler.HeapProfile,
@@ -66,69 +125,120 @@ func main() {
 	)
 	if err != nil {
 		logger.Warn("Failed to start profiler", "error", err)
+	} else {
+		profilerStarted = true
 	}
-	defer profiler.Stop()
 
 	// Initialize DogStatsD client
-	statsdClient, err := statsd.New(fmt.Sprintf("%s:%s",
-		os.Getenv("DD_AGENT_HOST"),
-		"8125"),
+	// 用途: カスタムメトリクス（ユーザー作成数、キャッシュヒット率、SQLレイテンシー、リクエスト数）
+	// すべてのメトリクス名に "api." プレフィックスが付与される
+	statsdClient, err = statsd.New(cfg.Datadog.StatsdAddr(),
+		statsd.WithNamespace("api."),
 		statsd.WithTags([]string{
-			"env:" + os.Getenv("DD_ENV"),
-			"service:" + os.Getenv("DD_SERVICE"),
+			"env:" + cfg.Datadog.Env,
+			"service:" + cfg.Datadog.Service,
 		}),
 	)
 	if err != nil {
-		logger.Error("Failed to initialize StatsD client", "error", err)
-		os.Exit(1)
-	}
-	defer statsdClient.Close()
-
-	// Initialize MySQL with tracing
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		os.Getenv("MYSQL_USER"),
-		os.Getenv("MYSQL_PASSWORD"),
-		os.Getenv("MYSQL_HOST"),
-		os.Getenv("MYSQL_PORT"),
-		os.Getenv("MYSQL_DATABASE"),
-	)
-	db, err := sqltrace.Open("mysql", dsn, sqltrace.WithServiceName("mysql"))
-	if err != nil {
-		logger.Error("Failed to connect to MySQL", "error", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to initialize StatsD client: %w", err)
 	}
-	defer db.Close()
 
-	if err := db.Ping(); err != nil {
-		logger.Error("Failed to ping MySQL", "error", err)
-		os.Exit(1)
-	}
-	logger.Info("Successfully connected to MySQL")
+	appMetrics := metrics.NewStatsdMetrics(statsdClient)
 
-	// Initialize Redis with tracing
-	redisClient := redistrace.NewClient(&redis.Options{
-		Addr: fmt.Sprintf("%s:%s",
-			os.Getenv("REDIS_HOST"),
-			os.Getenv("REDIS_PORT"),
-		),
-	}, redistrace.WithServiceName("redis"))
+	// Setup repositories
+	var repoLocator *appcontext.RepoLocator
+	if cfg.App.Storage == config.StorageMemory {
+		// STORAGE=memory runs without MySQL and Redis (demos, local development)
+		logger.Info("Using in-memory storage, MySQL and Redis are not used")
+		repoLocator = SetupMemoryRepositories(cfg, logger, appMetrics)
+	} else {
+		// Initialize MySQL with tracing
+		db, err := sqltrace.Open("mysql", cfg.MySQL.DSN(),
+			sqltrace.WithServiceName("mysql"), sqltrace.WithCustomTag("db.role", database.RolePrimary))
+		if err != nil {
+			return fmt.Errorf("failed to connect to MySQL: %w", err)
+		}
+		defer db.Close()
 
-	ctx := context.Background()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		logger.Error("Failed to connect to Redis", "error", err)
-		os.Exit(1)
+		if err := db.Ping(); err != nil {
+			return fmt.Errorf("failed to ping MySQL: %w", err)
+		}
+		logger.Info("Successfully connected to MySQL")
+
+		// Read replicas are optional and not pinged here: UserRepository reads
+		// use a replica once its health check passes, and the primary until then
+		replicas, err := openReplicas(cfg)
+		if err != nil {
+			return err
+		}
+		for _, replica := range replicas {
+			defer replica.Close()
+		}
+		if len(replicas) > 0 {
+			logger.Info("Routing user reads to MySQL replicas", "replicas", len(replicas))
+		}
+
+		// Initialize Redis with tracing
+		redisClient := redistrace.NewClient(&redis.Options{
+			Addr: cfg.Redis.Addr(),
+		}, redistrace.WithServiceName("redis"))
+		defer redisClient.Close()
+
+		if err := redisClient.Ping(context.Background()).Err(); err != nil {
+			return fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		logger.Info("Successfully connected to Redis")
+
+		// Replica health checks stop when run returns, before the replicas are closed
+		monitorCtx, stopMonitor := context.WithCancel(context.Background())
+		defer stopMonitor()
+
+		var closeCache func() error
+		repoLocator, closeCache = SetupRepositories(monitorCtx, cfg, db, replicas, redisClient, logger, appMetrics)
+		// Deferred after redisClient.Close, so it runs before it
+		defer func() {
+			if err := closeCache(); err != nil {
+				logger.Warn("Failed to stop cache invalidation listener", "error", err)
+			}
+		}()
 	}
-	logger.Info("Successfully connected to Redis")
 
-	// Setup repositories and router
-	repoLocator := SetupRepositories(db, redisClient, logger)
-	e := SetupRouter(logger, repoLocator)
+	// Setup router
+	e := SetupRouter(cfg, logger, repoLocator)
+
+	// Cancel ctx on SIGINT (Ctrl+C) or SIGTERM (docker stop / Kubernetes rollout)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Start Echo server
-	port := "8080"
-	logger.Info("Starting server", "port", port)
-	if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
-		logger.Error("Server failed to start", "error", err)
-		os.Exit(1)
+	port := strconv.Itoa(cfg.App.Port)
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Starting server", "port", port)
+		if err := e.Start(":" + port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("server failed to start: %w", err)
+	case <-ctx.Done():
 	}
+
+	// Stop accepting new connections and wait for in-flight requests
+	logger.Info("Shutdown signal received, draining in-flight requests",
+		"timeout", cfg.App.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
+	defer cancel()
+
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		// Deadline exceeded: force-close remaining connections but still flush telemetry
+		logger.Warn("Graceful shutdown timed out, closing remaining connections", "error", err)
+		e.Close()
+	} else {
+		logger.Info("All in-flight requests drained")
+	}
+
+	return nil
 }
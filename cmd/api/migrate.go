@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/config"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/database"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
+	"github.com/kanehiroyuu/datadog-tour/migrations"
+	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// migrationsDir is where `migrate create` writes new files, relative to the
+// working directory, which must be the repository root
+const migrationsDir = "migrations"
+
+// migrationsPackage marks migrationsDir as the embedded migrations package
+const migrationsPackage = "migrations.go"
+
+// migrationNamePattern restricts migration names to what LoadMigrations accepts
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// migrateUsage documents the migrate subcommand
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [n]       roll back the last n applied migrations (default 1)
+  status         list migrations and whether they are applied
+  create <name>  write a new empty up/down pair to ./migrations
+                 (run from the repository root)`
+
+// runMigrate implements `api migrate ...` against the configured MySQL DSN
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// create only writes files, so it needs neither config nor a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		return createMigration(migrationsDir, args[1])
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := SetupRedaction(cfg); err != nil {
+		return err
+	}
+
+	tracer.Start(
+		tracer.WithEnv(cfg.Datadog.Env),
+		tracer.WithService(cfg.Datadog.Service),
+		tracer.WithServiceVersion(cfg.Datadog.Version),
+	)
+	defer tracer.Stop()
+
+	db, err := sqltrace.Open("mysql", cfg.MySQL.DSN(), sqltrace.WithServiceName("mysql"))
+	if err != nil {
+		return fmt.Errorf("failed to connect to MySQL: %w", err)
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db, migrations.FS, logger, metrics.NewNoopMetrics())
+	if err != nil {
+		return err
+	}
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "migrate."+args[0])
+	defer span.Finish()
+
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		logger.Info("Migrations applied", "count", n)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("down expects a positive number of steps, got %q", args[1])
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		logger.Info("Migrations rolled back", "count", n)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// createMigration writes an empty up/down pair numbered after the newest migration in dir
+// dir must contain the migrations package, so files are never written to a
+// directory that is not embedded into the binary
+func createMigration(dir, name string) error {
+	name = strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	if !migrationNamePattern.MatchString(name) {
+		return fmt.Errorf("migration name must match %s, got %q", migrationNamePattern, name)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, migrationsPackage)); err != nil {
+		return fmt.Errorf("%s not found; run migrate create from the repository root", filepath.Join(dir, migrationsPackage))
+	}
+
+	existing, err := database.LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	var version int64 = 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s: %s\n", direction, name)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return fmt.Errorf("failed to create migration: %w", err)
+		}
+		fmt.Println("created", file)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kanehiroyuu/datadog-tour/fixtures"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/config"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/database"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase"
+	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/yaml.v3"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runSeed implements `api seed ...`: load fixture sets and/or synthetic data through the repositories
+//
+//	api seed                              # the embedded "sample" set
+//	api seed -set sample -file more.json  # embedded sets and files, in order
+//	api seed -users 10000 -orders 50000 -seed 42
+func runSeed(args []string) error {
+	var sets, files stringList
+	fset := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fset.Var(&sets, "set", "embedded fixture set to load (repeatable), e.g. sample")
+	fset.Var(&files, "file", "YAML or JSON fixture file to load (repeatable)")
+	users := fset.Int("users", 0, "number of synthetic users to generate")
+	orders := fset.Int("orders", 0, "number of synthetic orders to generate")
+	seed := fset.Uint64("seed", 1, "random seed for synthetic data; the same seed gives the same data")
+	firstOrderID := fset.Int("first-order-id", 1000000, "ID of the first synthetic order")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+
+	if *orders > 0 && *users == 0 {
+		return errors.New("-orders requires -users")
+	}
+	if len(sets) == 0 && len(files) == 0 && *users == 0 {
+		sets = append(sets, "sample")
+	}
+
+	// Parse everything before touching the database
+	var batches []usecase.Fixtures
+	for _, name := range sets {
+		f, err := loadFixtureSet(name)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, f)
+	}
+	for _, path := range files {
+		f, err := loadFixtureFile(path)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, f)
+	}
+	if *users > 0 {
+		batches = append(batches, usecase.GenerateFixtures(usecase.GenerateOptions{
+			Users:        *users,
+			Orders:       *orders,
+			Seed:         *seed,
+			FirstOrderID: *firstOrderID,
+		}))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	if err := SetupRedaction(cfg); err != nil {
+		return err
+	}
+
+	tracer.Start(
+		tracer.WithEnv(cfg.Datadog.Env),
+		tracer.WithService(cfg.Datadog.Service),
+		tracer.WithServiceVersion(cfg.Datadog.Version),
+	)
+	defer tracer.Stop()
+
+	db, err := sqltrace.Open("mysql", cfg.MySQL.DSN(), sqltrace.WithServiceName("mysql"))
+	if err != nil {
+		return fmt.Errorf("failed to connect to MySQL: %w", err)
+	}
+	defer db.Close()
+
+	noop := metrics.NewNoopMetrics()
+	uc := &usecase.SeedUseCase{
+		Logger: logger,
+		RUser:  database.NewUserRepository(db, logger, noop),
+		ROrder: database.NewOrderRepository(db, logger, noop),
+	}
+
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "seed")
+	defer span.Finish()
+
+	var total usecase.SeedResult
+	for _, batch := range batches {
+		result, err := uc.Seed(ctx, batch)
+		total.Users += result.Users
+		total.Orders += result.Orders
+		total.OrdersSkipped += result.OrdersSkipped
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Seeding completed", "users", total.Users, "orders", total.Orders, "orders_skipped", total.OrdersSkipped)
+	return nil
+}
+
+// loadFixtureSet reads an embedded fixture set by name
+func loadFixtureSet(name string) (usecase.Fixtures, error) {
+	for _, ext := range []string{".yaml", ".json"} {
+		data, err := fs.ReadFile(fixtures.FS, name+ext)
+		if err == nil {
+			return parseFixtures(name+ext, data)
+		}
+	}
+	return usecase.Fixtures{}, fmt.Errorf("unknown fixture set %q", name)
+}
+
+// loadFixtureFile reads a fixture file from disk
+func loadFixtureFile(path string) (usecase.Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return usecase.Fixtures{}, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+	return parseFixtures(path, data)
+}
+
+// parseFixtures decodes JSON or YAML by file extension, rejecting unknown keys
+func parseFixtures(name string, data []byte) (usecase.Fixtures, error) {
+	var f usecase.Fixtures
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return f, fmt.Errorf("failed to parse fixtures %s: %w", name, err)
+		}
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&f); err != nil {
+			return f, fmt.Errorf("failed to parse fixtures %s: %w", name, err)
+		}
+	default:
+		return f, fmt.Errorf("fixtures %s must be .yaml, .yml or .json", name)
+	}
+
+	return f, nil
+}
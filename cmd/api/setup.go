@@ -1,41 +1,172 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/common/config"
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
 	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/database"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/datadog"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/memory"
 	infraredis "github.com/kanehiroyuu/datadog-tour/internal/infrastructure/redis"
 	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/tracing"
 	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/handler"
 	"github.com/kanehiroyuu/datadog-tour/internal/presentation/router"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/cacheaside"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	sqltrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql"
 )
 
+// SetupRedaction installs the PII redaction policy used by logs, span tags and problem details
+func SetupRedaction(cfg *config.Config) error {
+	policy, err := redact.NewPolicy(cfg.Redact.SQLColumns, cfg.Redact.Attributes, cfg.Redact.HashKey)
+	if err != nil {
+		return fmt.Errorf("failed to build redaction policy: %w", err)
+	}
+	redact.SetDefault(policy)
+	return nil
+}
+
+// openReplicas opens the read replicas in MYSQL_REPLICA_DSNS without connecting
+func openReplicas(cfg *config.Config) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for i, dsn := range cfg.MySQL.Replicas() {
+		replica, err := sqltrace.Open("mysql", dsn,
+			sqltrace.WithServiceName("mysql"), sqltrace.WithCustomTag("db.role", database.RoleReplica))
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to open %s: %w", database.ReplicaName(i), err)
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas, nil
+}
+
 // SetupRepositories creates and configures all repositories
-func SetupRepositories(db *sql.DB, redisClient redis.UniversalClient, logger *slog.Logger) *appcontext.RepoLocator {
+// UserRepository reads go to replicas when any are given; replica health is
+// checked until ctx is done
+// The returned func stops the local cache's invalidation subscription; call it
+// before closing redisClient
+func SetupRepositories(ctx context.Context, cfg *config.Config, db *sql.DB, replicas []*sql.DB, redisClient redis.UniversalClient, logger *slog.Logger, metrics port.Metrics) (*appcontext.RepoLocator, func() error) {
 	// Setup repositories
-	userRepo := database.NewUserRepository(db, logger)
-	cacheRepoBase := infraredis.NewCacheRepository(redisClient)
-	cacheRepo := tracing.NewCacheRepositoryTracer(cacheRepoBase, cacheRepoBase.GetTTL())
+	slowQuery := database.WithSlowQuery(database.SlowQueryOptions{
+		Warn:           cfg.MySQL.SlowQueryWarn,
+		Error:          cfg.MySQL.SlowQueryError,
+		Explain:        cfg.MySQL.ExplainSlowQueries,
+		ExplainTimeout: cfg.MySQL.ExplainTimeout,
+	})
+	// One QueryStats aggregates the statements of every repository
+	queryStats := database.NewQueryStats(cfg.MySQL.StatsWindow, cfg.MySQL.StatsMaxFingerprints)
+	withStats := database.WithQueryStats(queryStats)
+	primary := database.WithRole(database.RolePrimary)
+	var userRepo port.UserRepository = database.NewUserRepository(db, logger, metrics, slowQuery, withStats, primary)
+	if len(replicas) > 0 {
+		router := database.NewRouter(ctx, db, replicas, database.ReplicaOptions{
+			HealthInterval: cfg.MySQL.ReplicaHealthInterval,
+			HealthTimeout:  cfg.Health.MySQLTimeout,
+		}, logger, metrics, slowQuery, withStats)
+		userRepo = database.NewRoutedUserRepository(router)
+	}
+	orderRepo := database.NewOrderRepository(db, logger, metrics, slowQuery, withStats, primary)
+	unitOfWork := database.NewUnitOfWork(db, logger, metrics, slowQuery, withStats, primary)
+	cacheRepoBase := infraredis.NewCacheRepository(redisClient, cfg.Cache.TTL)
+	cacheRepo := tracing.NewCacheRepositoryTracer(cacheRepoBase, metrics)
+	closeCache := func() error { return nil }
+	if cfg.Cache.LocalEnabled {
+		// Local hits skip Redis entirely, so they produce a cache.get span without a redis.get child
+		tiered := infraredis.NewTieredCacheRepository(cacheRepo, redisClient, infraredis.TieredOptions{
+			Size:    cfg.Cache.LocalSize,
+			TTL:     cfg.Cache.LocalTTL,
+			Channel: cfg.Cache.InvalidationChannel,
+		}, logger, metrics)
+		cacheRepo = tiered
+		closeCache = tiered.Close
+	}
+
+	userCache := newUserCache(cfg, cacheRepo, logger, metrics)
+
+	// Setup readiness checks
+	healthChecks := []port.HealthChecker{
+		database.NewHealthCheck(db, cfg.Health.MySQLTimeout),
+		infraredis.NewHealthCheck(redisClient, cfg.Health.RedisTimeout),
+	}
+	for i, replica := range replicas {
+		healthChecks = append(healthChecks,
+			database.NewReplicaHealthCheck(database.ReplicaName(i), replica, cfg.Health.MySQLTimeout))
+	}
+	if cfg.Health.CheckAgent {
+		healthChecks = append(healthChecks,
+			datadog.NewAgentHealthCheck(cfg.Datadog.AgentHost, cfg.Datadog.TracePort, cfg.Health.AgentTimeout))
+	}
 
 	// Setup RepoLocator
 	return &appcontext.RepoLocator{
-		UserRepo:  userRepo,
-		CacheRepo: cacheRepo,
+		UserRepo:     userRepo,
+		OrderRepo:    orderRepo,
+		UnitOfWork:   unitOfWork,
+		CacheRepo:    cacheRepo,
+		Metrics:      metrics,
+		UserCache:    userCache,
+		HealthChecks: healthChecks,
+		QueryStats:   queryStats,
+	}, closeCache
+}
+
+// SetupMemoryRepositories creates in-process repositories for STORAGE=memory
+// Data is lost on restart, and readiness only depends on the optional agent check
+func SetupMemoryRepositories(cfg *config.Config, logger *slog.Logger, metrics port.Metrics) *appcontext.RepoLocator {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	orderRepo := memory.NewOrderRepository(store)
+	unitOfWork := memory.NewUnitOfWork(store)
+	cacheRepo := tracing.NewCacheRepositoryTracer(memory.NewCacheRepository(cfg.Cache.TTL), metrics)
+
+	var healthChecks []port.HealthChecker
+	if cfg.Health.CheckAgent {
+		healthChecks = append(healthChecks,
+			datadog.NewAgentHealthCheck(cfg.Datadog.AgentHost, cfg.Datadog.TracePort, cfg.Health.AgentTimeout))
+	}
+
+	return &appcontext.RepoLocator{
+		UserRepo:     userRepo,
+		OrderRepo:    orderRepo,
+		UnitOfWork:   unitOfWork,
+		CacheRepo:    cacheRepo,
+		Metrics:      metrics,
+		UserCache:    newUserCache(cfg, cacheRepo, logger, metrics),
+		HealthChecks: healthChecks,
 	}
 }
 
+// newUserCache creates the shared cache-aside helper for users
+func newUserCache(cfg *config.Config, cacheRepo port.CacheRepository, logger *slog.Logger, metrics port.Metrics) *cacheaside.Cache[entities.User] {
+	return cacheaside.New[entities.User](cacheRepo, metrics, logger, "user", cacheaside.Options{
+		TTL:         cfg.Cache.TTL,
+		NegativeTTL: cfg.Cache.NegativeTTL,
+		Jitter:      cfg.Cache.TTLJitter,
+		NotFound:    entities.ErrUserNotFound,
+	})
+}
+
 // SetupRouter creates and configures the application router with all handlers
-func SetupRouter(logger *slog.Logger, repoLocator *appcontext.RepoLocator) *echo.Echo {
+func SetupRouter(cfg *config.Config, logger *slog.Logger, repoLocator *appcontext.RepoLocator) *echo.Echo {
 	// Setup handlers
 	healthHandler := handler.NewHealthHandler()
 	userHandler := handler.NewUserHandler()
+	orderHandler := handler.NewOrderHandler()
 	testHandler := handler.NewTestHandler()
+	adminHandler := handler.NewAdminHandler()
 
 	// Setup router with tracing
-	return router.Setup(userHandler, healthHandler, testHandler, logger, repoLocator)
+	return router.Setup(userHandler, orderHandler, healthHandler, testHandler, adminHandler, cfg.App.AdminToken, int64(cfg.App.MaxBodyBytes), cfg.Datadog.Service, logger, repoLocator)
 }
@@ -0,0 +1,11 @@
+// Package fixtures embeds the named fixture sets loaded by `api seed`
+//
+// Each <set>.yaml (or .json) holds users and orders; see usecase.Fixtures
+package fixtures
+
+import "embed"
+
+// FS holds every fixture set compiled into the binary
+//
+//go:embed *.yaml
+var FS embed.FS
@@ -8,7 +8,9 @@ require (
 	github.com/go-sql-driver/mysql v1.8.1
 	github.com/labstack/echo/v4 v4.13.4
 	github.com/redis/go-redis/v9 v9.7.3
+	golang.org/x/sync v0.15.0
 	gopkg.in/DataDog/dd-trace-go.v1 v1.74.8
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -103,5 +105,4 @@ require (
 	google.golang.org/grpc v1.72.0 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
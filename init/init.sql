@@ -1,39 +0,0 @@
--- Create users table
-CREATE TABLE IF NOT EXISTS users (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    name VARCHAR(255) NOT NULL,
-    email VARCHAR(255) NOT NULL UNIQUE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    INDEX idx_email (email),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Insert sample data
-INSERT INTO users (name, email) VALUES
-    ('Alice Johnson', 'alice@example.com'),
-    ('Bob Smith', 'bob@example.com'),
-    ('Charlie Brown', 'charlie@example.com'),
-    ('Diana Prince', 'diana@example.com'),
-    ('Ethan Hunt', 'ethan@example.com');
-
--- Create orders table (for future use)
-CREATE TABLE IF NOT EXISTS orders (
-    id INT AUTO_INCREMENT PRIMARY KEY,
-    user_id INT NOT NULL,
-    product_name VARCHAR(255) NOT NULL,
-    amount DECIMAL(10, 2) NOT NULL,
-    status ENUM('pending', 'completed', 'cancelled') DEFAULT 'pending',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id),
-    INDEX idx_user_id (user_id),
-    INDEX idx_status (status),
-    INDEX idx_created_at (created_at)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- Insert sample orders
-INSERT INTO orders (user_id, product_name, amount, status) VALUES
-    (1, 'Laptop', 999.99, 'completed'),
-    (1, 'Mouse', 29.99, 'completed'),
-    (2, 'Keyboard', 79.99, 'pending'),
-    (3, 'Monitor', 299.99, 'completed'),
-    (4, 'Headphones', 149.99, 'pending');
@@ -0,0 +1,175 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error for HTTP status, problem type and alerting
+type Kind string
+
+// Error kinds
+const (
+	KindValidation       Kind = "validation_error"
+	KindBadRequest       Kind = "bad_request"
+	KindUnauthorized     Kind = "unauthorized"
+	KindForbidden        Kind = "forbidden"
+	KindNotFound         Kind = "not_found"
+	KindMethodNotAllowed Kind = "method_not_allowed"
+	KindConflict         Kind = "conflict"
+	KindPayloadTooLarge  Kind = "payload_too_large"
+	KindUnavailable      Kind = "service_unavailable"
+	KindInternal         Kind = "system_error"
+)
+
+// Problem type URIs (RFC 9457 "type" member)
+const (
+	TypeValidation     = "https://datadog-tour.example.com/errors/validation"
+	TypeNotFound       = "https://datadog-tour.example.com/errors/not-found"
+	TypeConflict       = "https://datadog-tour.example.com/errors/conflict"
+	TypeUnauthorized   = "https://datadog-tour.example.com/errors/unauthorized"
+	TypeForbidden      = "https://datadog-tour.example.com/errors/forbidden"
+	TypeInternal       = "https://datadog-tour.example.com/errors/internal"
+	TypeBadRequest     = "https://datadog-tour.example.com/errors/bad-request"
+	TypeServiceUnavail = "https://datadog-tour.example.com/errors/service-unavailable"
+	TypeMethodNotAllow = "https://datadog-tour.example.com/errors/method-not-allowed"
+	TypeTooLarge       = "https://datadog-tour.example.com/errors/payload-too-large"
+)
+
+// Definition describes how an error kind is reported to clients and on-call
+type Definition struct {
+	Kind   Kind
+	Status int    // HTTP status code
+	Type   string // RFC 9457 problem type URI
+	Title  string // RFC 9457 problem title
+	Notify bool   // Whether the error should trigger alerts (error.notify)
+}
+
+// catalog is the single source of truth for error reporting
+// Only system failures notify; caller mistakes are expected and do not page
+var catalog = map[Kind]Definition{
+	KindValidation:       {KindValidation, http.StatusBadRequest, TypeValidation, "Validation Error", false},
+	KindBadRequest:       {KindBadRequest, http.StatusBadRequest, TypeBadRequest, "Bad Request", false},
+	KindUnauthorized:     {KindUnauthorized, http.StatusUnauthorized, TypeUnauthorized, "Unauthorized", false},
+	KindForbidden:        {KindForbidden, http.StatusForbidden, TypeForbidden, "Forbidden", false},
+	KindNotFound:         {KindNotFound, http.StatusNotFound, TypeNotFound, "Not Found", false},
+	KindMethodNotAllowed: {KindMethodNotAllowed, http.StatusMethodNotAllowed, TypeMethodNotAllow, "Method Not Allowed", false},
+	KindConflict:         {KindConflict, http.StatusConflict, TypeConflict, "Conflict", false},
+	KindPayloadTooLarge:  {KindPayloadTooLarge, http.StatusRequestEntityTooLarge, TypeTooLarge, "Payload Too Large", false},
+	KindUnavailable:      {KindUnavailable, http.StatusServiceUnavailable, TypeServiceUnavail, "Service Unavailable", true},
+	KindInternal:         {KindInternal, http.StatusInternalServerError, TypeInternal, "Internal Server Error", true},
+}
+
+// Lookup returns the definition for a kind, defaulting to internal error
+func Lookup(kind Kind) Definition {
+	if def, ok := catalog[kind]; ok {
+		return def
+	}
+	return catalog[KindInternal]
+}
+
+// KindFromStatus returns the kind for an HTTP status code
+// Used to bring errors raised outside the catalog (e.g. by Echo) into it
+func KindFromStatus(status int) Kind {
+	switch {
+	case status == http.StatusNotFound:
+		return KindNotFound
+	case status == http.StatusMethodNotAllowed:
+		return KindMethodNotAllowed
+	case status == http.StatusUnauthorized:
+		return KindUnauthorized
+	case status == http.StatusForbidden:
+		return KindForbidden
+	case status == http.StatusConflict:
+		return KindConflict
+	case status == http.StatusRequestEntityTooLarge:
+		return KindPayloadTooLarge
+	case status == http.StatusServiceUnavailable:
+		return KindUnavailable
+	case status >= 400 && status < 500:
+		return KindBadRequest
+	default:
+		return KindInternal
+	}
+}
+
+// Error is an application error with a kind from the catalog
+// Message is safe to show to clients; Err is the underlying cause, if any
+// Extra holds RFC 9457 extension members for the problem, e.g. the rejected input
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+	Extra   map[string]any
+}
+
+// New creates an application error of the given kind
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap creates an application error of the given kind around a cause
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// WithExtra adds an extension member to the problem reported for e and returns e
+// Only call it on a new Error, never on a shared one such as entities.ErrUserNotFound
+func (e *Error) WithExtra(key string, value any) *Error {
+	if e.Extra == nil {
+		e.Extra = make(map[string]any)
+	}
+	e.Extra[key] = value
+	return e
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Resolve returns the definition for any error and the client-facing message
+// The outermost apperror of a specific kind decides, so a handler may wrap any
+// use case error as KindInternal and a not-found or conflict still shows
+// through; the internal wrapper only supplies the message otherwise
+// Errors outside the catalog are treated as internal errors with no message,
+// so that system details never leak to clients
+func Resolve(err error) (Definition, string) {
+	var internal *Error
+	var appErr *Error
+	for cur := err; errors.As(cur, &appErr); cur = appErr.Err {
+		if def := Lookup(appErr.Kind); def.Kind != KindInternal {
+			return def, appErr.Message
+		}
+		if internal == nil {
+			internal = appErr
+		}
+	}
+	if internal != nil {
+		return catalog[KindInternal], internal.Message
+	}
+	return catalog[KindInternal], ""
+}
+
+// Extras collects the Extra members of every apperror in err's chain
+// Outer errors win, so a handler can override what a use case attached
+func Extras(err error) map[string]any {
+	extras := make(map[string]any)
+	var appErr *Error
+	for cur := err; errors.As(cur, &appErr); cur = appErr.Err {
+		for key, value := range appErr.Extra {
+			if _, ok := extras[key]; !ok {
+				extras[key] = value
+			}
+		}
+	}
+	return extras
+}
@@ -0,0 +1,414 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed application configuration
+//
+// Values are resolved in this order (later wins):
+//  1. defaults (`default` tag)
+//  2. optional YAML file pointed to by CONFIG_FILE
+//  3. environment variables (`env` tag)
+//
+// Fields tagged `secret:"true"` are redacted when the config is logged
+type Config struct {
+	App     AppConfig     `yaml:"app"`
+	Datadog DatadogConfig `yaml:"datadog"`
+	MySQL   MySQLConfig   `yaml:"mysql"`
+	Redis   RedisConfig   `yaml:"redis"`
+	Cache   CacheConfig   `yaml:"cache"`
+	Health  HealthConfig  `yaml:"health"`
+	Redact  RedactConfig  `yaml:"redact"`
+}
+
+// AppConfig holds HTTP server settings
+type AppConfig struct {
+	Port            int           `yaml:"port" env:"APP_PORT" default:"8080"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"20s"`
+	Storage         string        `yaml:"storage" env:"STORAGE" default:"mysql"`
+	// MaxBodyBytes caps request bodies; larger requests get 413
+	MaxBodyBytes int `yaml:"max_body_bytes" env:"MAX_REQUEST_BODY_BYTES" default:"1048576"`
+	// AdminToken is the bearer token for /admin endpoints; empty disables them
+	AdminToken string `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
+}
+
+// Storage backends selected by STORAGE
+const (
+	// StorageMySQL stores data in MySQL and caches it in Redis
+	StorageMySQL = "mysql"
+	// StorageMemory keeps data and cache in process; MySQL and Redis are not used
+	StorageMemory = "memory"
+)
+
+// DatadogConfig holds unified service tagging and agent settings
+type DatadogConfig struct {
+	Env        string `yaml:"env" env:"DD_ENV"`
+	Service    string `yaml:"service" env:"DD_SERVICE" default:"datadog-tour-api"`
+	Version    string `yaml:"version" env:"DD_VERSION"`
+	AgentHost  string `yaml:"agent_host" env:"DD_AGENT_HOST" default:"localhost"`
+	StatsdPort int    `yaml:"statsd_port" env:"DD_DOGSTATSD_PORT" default:"8125"`
+	TracePort  int    `yaml:"trace_port" env:"DD_TRACE_AGENT_PORT" default:"8126"`
+}
+
+// MySQLConfig holds MySQL connection settings
+type MySQLConfig struct {
+	Host     string `yaml:"host" env:"MYSQL_HOST" required:"true"`
+	Port     int    `yaml:"port" env:"MYSQL_PORT" default:"3306"`
+	User     string `yaml:"user" env:"MYSQL_USER" required:"true"`
+	Password string `yaml:"password" env:"MYSQL_PASSWORD" secret:"true"`
+	Database string `yaml:"database" env:"MYSQL_DATABASE" required:"true"`
+	// Slow queries: SQL logs go to WARN above SlowQueryWarn and ERROR above SlowQueryError (0 disables)
+	SlowQueryWarn  time.Duration `yaml:"slow_query_warn" env:"MYSQL_SLOW_QUERY_WARN" default:"200ms"`
+	SlowQueryError time.Duration `yaml:"slow_query_error" env:"MYSQL_SLOW_QUERY_ERROR" default:"1s"`
+	// ExplainSlowQueries runs EXPLAIN FORMAT=JSON in the background for slow SELECTs
+	ExplainSlowQueries bool          `yaml:"explain_slow_queries" env:"MYSQL_EXPLAIN_SLOW_QUERIES" default:"false"`
+	ExplainTimeout     time.Duration `yaml:"explain_timeout" env:"MYSQL_EXPLAIN_TIMEOUT" default:"2s"`
+	// Per-fingerprint SQL stats: percentiles cover the last StatsWindow executions,
+	// and fingerprints beyond StatsMaxFingerprints are grouped as "other"
+	StatsWindow          int `yaml:"stats_window" env:"MYSQL_STATS_WINDOW" default:"1000"`
+	StatsMaxFingerprints int `yaml:"stats_max_fingerprints" env:"MYSQL_STATS_MAX_FINGERPRINTS" default:"500"`
+	// ReplicaDSNs lists read replicas as comma-separated go-sql-driver/mysql DSNs
+	// (parseTime=true is required); empty sends every query to the primary
+	ReplicaDSNs           string        `yaml:"replica_dsns" env:"MYSQL_REPLICA_DSNS" secret:"true"`
+	ReplicaHealthInterval time.Duration `yaml:"replica_health_interval" env:"MYSQL_REPLICA_HEALTH_INTERVAL" default:"5s"`
+}
+
+// RedisConfig holds Redis connection settings
+type RedisConfig struct {
+	Host string `yaml:"host" env:"REDIS_HOST" required:"true"`
+	Port int    `yaml:"port" env:"REDIS_PORT" default:"6379"`
+}
+
+// CacheConfig holds cache settings
+type CacheConfig struct {
+	TTL         time.Duration `yaml:"ttl" env:"CACHE_TTL" default:"5m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env:"CACHE_NEGATIVE_TTL" default:"30s"`
+	TTLJitter   float64       `yaml:"ttl_jitter" env:"CACHE_TTL_JITTER" default:"0.1"`
+	// Local tier: an in-process LRU in front of Redis, invalidated over pub/sub
+	LocalEnabled        bool          `yaml:"local_enabled" env:"CACHE_LOCAL_ENABLED" default:"false"`
+	LocalSize           int           `yaml:"local_size" env:"CACHE_LOCAL_SIZE" default:"10000"`
+	LocalTTL            time.Duration `yaml:"local_ttl" env:"CACHE_LOCAL_TTL" default:"5s"`
+	InvalidationChannel string        `yaml:"invalidation_channel" env:"CACHE_INVALIDATION_CHANNEL" default:"cache:invalidate"`
+}
+
+// HealthConfig holds readiness probe settings
+// Each dependency has its own timeout so a slow agent cannot mask a healthy database
+type HealthConfig struct {
+	MySQLTimeout time.Duration `yaml:"mysql_timeout" env:"HEALTH_MYSQL_TIMEOUT" default:"1s"`
+	RedisTimeout time.Duration `yaml:"redis_timeout" env:"HEALTH_REDIS_TIMEOUT" default:"500ms"`
+	CheckAgent   bool          `yaml:"check_agent" env:"HEALTH_CHECK_AGENT" default:"false"`
+	AgentTimeout time.Duration `yaml:"agent_timeout" env:"HEALTH_AGENT_TIMEOUT" default:"500ms"`
+}
+
+// RedactConfig holds the PII redaction policy (see internal/common/redact)
+// Rules are comma-separated key=action pairs; actions are mask, hash and drop
+type RedactConfig struct {
+	// SQLColumns redacts arguments bound to these columns in SQL logs
+	SQLColumns string `yaml:"sql_columns" env:"REDACT_SQL_COLUMNS" default:"email=mask,name=mask"`
+	// Attributes redacts log attributes, span tags and problem extras with these keys
+	Attributes string `yaml:"attributes" env:"REDACT_ATTRIBUTES" default:"user.email=mask,user.name=mask"`
+	// HashKey keys the hash action so hashed values cannot be guessed; hash
+	// rules are rejected without it
+	HashKey string `yaml:"hash_key" env:"REDACT_HASH_KEY" secret:"true"`
+}
+
+// DSN returns the go-sql-driver/mysql data source name
+func (c MySQLConfig) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
+		c.User, c.Password, c.Host, c.Port, c.Database)
+}
+
+// Replicas returns the read replica DSNs, or nil when none are configured
+func (c MySQLConfig) Replicas() []string {
+	var dsns []string
+	for _, dsn := range strings.Split(c.ReplicaDSNs, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+	return dsns
+}
+
+// Addr returns the Redis host:port address
+func (c RedisConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// StatsdAddr returns the DogStatsD host:port address
+func (c DatadogConfig) StatsdAddr() string {
+	return fmt.Sprintf("%s:%d", c.AgentHost, c.StatsdPort)
+}
+
+// Load builds the configuration from defaults, the optional CONFIG_FILE and env
+// Returns an error listing every missing or invalid key
+func Load() (*Config, error) {
+	cfg := &Config{}
+
+	if err := walk(cfg, applyDefault); err != nil {
+		return nil, err
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := walk(cfg, applyEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks required keys and value ranges
+func (c *Config) Validate() error {
+	var errs []error
+
+	walk(c, func(section string, field reflect.StructField, v reflect.Value) error {
+		if !c.usesSection(section) {
+			return nil
+		}
+		if field.Tag.Get("required") == "true" && v.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", field.Tag.Get("env")))
+		}
+		return nil
+	})
+
+	for _, p := range []struct {
+		name string
+		port int
+	}{
+		{"APP_PORT", c.App.Port},
+		{"DD_DOGSTATSD_PORT", c.Datadog.StatsdPort},
+		{"MYSQL_PORT", c.MySQL.Port},
+		{"REDIS_PORT", c.Redis.Port},
+	} {
+		if p.port <= 0 || p.port > 65535 {
+			errs = append(errs, fmt.Errorf("%s must be between 1 and 65535, got %d", p.name, p.port))
+		}
+	}
+
+	if c.App.Storage != StorageMySQL && c.App.Storage != StorageMemory {
+		errs = append(errs, fmt.Errorf("STORAGE must be %q or %q, got %q", StorageMySQL, StorageMemory, c.App.Storage))
+	}
+
+	if c.App.MaxBodyBytes <= 0 {
+		errs = append(errs, fmt.Errorf("MAX_REQUEST_BODY_BYTES must be positive, got %d", c.App.MaxBodyBytes))
+	}
+
+	if c.App.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("SHUTDOWN_TIMEOUT must be positive, got %s", c.App.ShutdownTimeout))
+	}
+
+	if c.MySQL.SlowQueryWarn < 0 || c.MySQL.SlowQueryError < 0 {
+		errs = append(errs, errors.New("MYSQL_SLOW_QUERY_WARN and MYSQL_SLOW_QUERY_ERROR must not be negative"))
+	}
+	if c.MySQL.SlowQueryWarn > 0 && c.MySQL.SlowQueryError > 0 && c.MySQL.SlowQueryWarn > c.MySQL.SlowQueryError {
+		errs = append(errs, fmt.Errorf("MYSQL_SLOW_QUERY_WARN (%s) must not exceed MYSQL_SLOW_QUERY_ERROR (%s)", c.MySQL.SlowQueryWarn, c.MySQL.SlowQueryError))
+	}
+	if c.MySQL.ExplainSlowQueries && c.MySQL.ExplainTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("MYSQL_EXPLAIN_TIMEOUT must be positive, got %s", c.MySQL.ExplainTimeout))
+	}
+	if c.MySQL.StatsWindow <= 0 {
+		errs = append(errs, fmt.Errorf("MYSQL_STATS_WINDOW must be positive, got %d", c.MySQL.StatsWindow))
+	}
+	if c.MySQL.StatsMaxFingerprints <= 0 {
+		errs = append(errs, fmt.Errorf("MYSQL_STATS_MAX_FINGERPRINTS must be positive, got %d", c.MySQL.StatsMaxFingerprints))
+	}
+	if c.usesSection("mysql") {
+		// Errors name the entry, not the DSN, which contains the password
+		for i, dsn := range c.MySQL.Replicas() {
+			parsed, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("MYSQL_REPLICA_DSNS entry %d: %w", i+1, err))
+			} else if !parsed.ParseTime {
+				errs = append(errs, fmt.Errorf("MYSQL_REPLICA_DSNS entry %d must set parseTime=true", i+1))
+			}
+		}
+		if len(c.MySQL.Replicas()) > 0 && c.MySQL.ReplicaHealthInterval <= 0 {
+			errs = append(errs, fmt.Errorf("MYSQL_REPLICA_HEALTH_INTERVAL must be positive, got %s", c.MySQL.ReplicaHealthInterval))
+		}
+	}
+
+	if _, err := redact.NewPolicy(c.Redact.SQLColumns, c.Redact.Attributes, c.Redact.HashKey); err != nil {
+		errs = append(errs, fmt.Errorf("REDACT_SQL_COLUMNS/REDACT_ATTRIBUTES/REDACT_HASH_KEY: %w", err))
+	}
+
+	for _, t := range []struct {
+		name    string
+		timeout time.Duration
+		used    bool
+	}{
+		{"HEALTH_MYSQL_TIMEOUT", c.Health.MySQLTimeout, c.usesSection("mysql")},
+		{"HEALTH_REDIS_TIMEOUT", c.Health.RedisTimeout, c.usesSection("redis")},
+		{"HEALTH_AGENT_TIMEOUT", c.Health.AgentTimeout, c.Health.CheckAgent},
+	} {
+		if t.used && t.timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", t.name, t.timeout))
+		}
+	}
+
+	if c.Cache.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("CACHE_TTL must be positive, got %s", c.Cache.TTL))
+	}
+	if c.Cache.NegativeTTL < 0 {
+		errs = append(errs, fmt.Errorf("CACHE_NEGATIVE_TTL must not be negative, got %s", c.Cache.NegativeTTL))
+	}
+	// A jitter of 1 or more could make the TTL zero or negative
+	if c.Cache.TTLJitter < 0 || c.Cache.TTLJitter >= 1 {
+		errs = append(errs, fmt.Errorf("CACHE_TTL_JITTER must be at least 0 and less than 1, got %g", c.Cache.TTLJitter))
+	}
+
+	if c.Cache.LocalEnabled {
+		if c.Cache.LocalSize <= 0 {
+			errs = append(errs, fmt.Errorf("CACHE_LOCAL_SIZE must be positive, got %d", c.Cache.LocalSize))
+		}
+		if c.Cache.LocalTTL <= 0 {
+			errs = append(errs, fmt.Errorf("CACHE_LOCAL_TTL must be positive, got %s", c.Cache.LocalTTL))
+		}
+		if c.Cache.InvalidationChannel == "" {
+			errs = append(errs, errors.New("CACHE_INVALIDATION_CHANNEL is required when CACHE_LOCAL_ENABLED is true"))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// usesSection reports whether a config section is needed by the selected storage
+// MySQL and Redis settings are not required when running in memory
+func (c *Config) usesSection(section string) bool {
+	if c.App.Storage == StorageMemory {
+		return section != "mysql" && section != "redis"
+	}
+	return true
+}
+
+// LogValue implements slog.LogValuer so the effective config can be logged
+// directly; secret fields are replaced with "[REDACTED]"
+func (c *Config) LogValue() slog.Value {
+	return redactSecrets(reflect.ValueOf(*c))
+}
+
+// redactSecrets converts a config struct into a slog group, masking secrets
+func redactSecrets(v reflect.Value) slog.Value {
+	t := v.Type()
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("yaml")
+		fv := v.Field(i)
+
+		switch {
+		case fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Duration(0)):
+			attrs = append(attrs, slog.Attr{Key: name, Value: redactSecrets(fv)})
+		case field.Tag.Get("secret") == "true":
+			masked := ""
+			if !fv.IsZero() {
+				masked = "[REDACTED]"
+			}
+			attrs = append(attrs, slog.String(name, masked))
+		case fv.Type() == reflect.TypeOf(time.Duration(0)):
+			attrs = append(attrs, slog.String(name, fv.Interface().(time.Duration).String()))
+		default:
+			attrs = append(attrs, slog.Any(name, fv.Interface()))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// walk calls fn for every leaf field of the nested config structs
+// section is the yaml name of the enclosing struct (e.g. "mysql")
+func walk(cfg *Config, fn func(section string, field reflect.StructField, v reflect.Value) error) error {
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		name := root.Type().Field(i).Tag.Get("yaml")
+		section := root.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			if err := fn(name, section.Type().Field(j), section.Field(j)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyDefault sets a field from its `default` tag
+func applyDefault(_ string, field reflect.StructField, v reflect.Value) error {
+	if def, ok := field.Tag.Lookup("default"); ok {
+		return setValue(v, def, field.Tag.Get("env"))
+	}
+	return nil
+}
+
+// applyEnv sets a field from its `env` variable when the variable is set
+func applyEnv(_ string, field reflect.StructField, v reflect.Value) error {
+	name := field.Tag.Get("env")
+	if raw, ok := os.LookupEnv(name); ok && raw != "" {
+		return setValue(v, raw, name)
+	}
+	return nil
+}
+
+// setValue parses raw into the field according to its type
+func setValue(v reflect.Value, raw, name string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration (e.g. 5m), got %q", name, raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", name, raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a boolean, got %q", name, raw)
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", name, raw)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("%s has unsupported type %s", name, v.Type())
+	}
+	return nil
+}
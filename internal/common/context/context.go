@@ -4,7 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"sync/atomic"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/cacheaside"
 	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
 )
 
@@ -14,12 +17,24 @@ const (
 	loggerKey      contextKey = "logger"
 	repoLocatorKey contextKey = "repo_locator"
 	interactorKey  contextKey = "interactor"
+	readYourWrites contextKey = "read_your_writes"
+	primaryReads   contextKey = "primary_reads"
 )
 
-// RepoLocator holds all repositories
+// RepoLocator holds all repositories and the metrics client
 type RepoLocator struct {
 	UserRepo  port.UserRepository
+	OrderRepo port.OrderRepository
 	CacheRepo port.CacheRepository
+	Metrics   port.Metrics
+	// UnitOfWork runs writes across UserRepo and OrderRepo in one transaction
+	UnitOfWork port.UnitOfWork
+	// UserCache is shared across requests so concurrent misses are deduplicated
+	UserCache *cacheaside.Cache[entities.User]
+	// HealthChecks are the dependencies verified by the readiness probe
+	HealthChecks []port.HealthChecker
+	// QueryStats holds per-fingerprint SQL statistics; nil with STORAGE=memory
+	QueryStats port.QueryStatsReader
 }
 
 // RUser returns UserRepository
@@ -27,11 +42,26 @@ func (r *RepoLocator) RUser() port.UserRepository {
 	return r.UserRepo
 }
 
+// ROrder returns OrderRepository
+func (r *RepoLocator) ROrder() port.OrderRepository {
+	return r.OrderRepo
+}
+
+// UoW returns UnitOfWork
+func (r *RepoLocator) UoW() port.UnitOfWork {
+	return r.UnitOfWork
+}
+
 // RCache returns CacheRepository
 func (r *RepoLocator) RCache() port.CacheRepository {
 	return r.CacheRepo
 }
 
+// RMetrics returns Metrics
+func (r *RepoLocator) RMetrics() port.Metrics {
+	return r.Metrics
+}
+
 // SetLogger sets logger in context
 func SetLogger(ctx context.Context, logger *slog.Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
@@ -68,3 +98,36 @@ func SetInteractor(ctx context.Context, interactor any) context.Context {
 func GetInteractor(ctx context.Context) any {
 	return ctx.Value(interactorKey)
 }
+
+// WithReadYourWrites starts a read-your-writes scope, normally one per request
+// Once MarkWritten is called, replica-routed reads in the scope go to the primary
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWrites, new(atomic.Bool))
+}
+
+// MarkWritten records a write to the primary in the current scope
+// It does nothing outside a WithReadYourWrites scope
+func MarkWritten(ctx context.Context) {
+	if written, ok := ctx.Value(readYourWrites).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
+
+// HasWritten reports whether the current scope has written to the primary
+func HasWritten(ctx context.Context) bool {
+	written, ok := ctx.Value(readYourWrites).(*atomic.Bool)
+	return ok && written.Load()
+}
+
+// WithPrimaryReads makes replica-routed reads made with ctx go to the primary
+// Use it for reads whose result is cached or written back, where replication
+// lag would re-cache stale data or lose a newer update
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReads, true)
+}
+
+// ReadsPrimary reports whether ctx requires reads from the primary
+func ReadsPrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryReads).(bool)
+	return forced
+}
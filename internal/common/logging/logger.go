@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
@@ -71,29 +73,7 @@ func LogWarnWithTrace(ctx context.Context, logger *slog.Logger, layer, message s
 //
 //	LogErrorWithTrace(ctx, logger, "usecase", "Database connection failed", err, nil)
 func LogErrorWithTrace(ctx context.Context, logger *slog.Logger, layer, message string, err error, fields map[string]any) {
-	if fields == nil {
-		fields = make(map[string]any)
-	}
-
-	// Add error.notify field to logs
-	fields["error.notify"] = true
-	fields["error"] = err.Error()
-
-	if span, ok := tracer.SpanFromContext(ctx); ok {
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		span.SetTag("error.notify", true)
-
-		if errorType, ok := fields["error.type"]; ok {
-			span.SetTag("error.type", errorType)
-		} else {
-			span.SetTag("error.type", "system_error")
-		}
-	}
-
-	attrs := prepareLogAttrs(ctx, layer, fields, 2)
-	formattedMessage := formatLogMessage(layer, message)
-	logger.ErrorContext(ctx, formattedMessage, attrs...)
+	logError(ctx, logger, layer, message, err, fields, true, "system_error")
 }
 
 // LogErrorWithTraceNotNotify logs an error that should not trigger alerts
@@ -106,27 +86,51 @@ func LogErrorWithTrace(ctx context.Context, logger *slog.Logger, layer, message
 //	    "error.type": "validation_error",
 //	})
 func LogErrorWithTraceNotNotify(ctx context.Context, logger *slog.Logger, layer, message string, err error, fields map[string]any) {
+	logError(ctx, logger, layer, message, err, fields, false, "expected_error")
+}
+
+// LogError logs an error using the apperror catalog to decide alerting
+// error.notify and error.type come from the error's kind, so they always
+// agree with the problem detail returned to the client
+//
+// Example:
+//
+//	LogError(ctx, logger, "usecase", "Failed to create user", err, nil)
+func LogError(ctx context.Context, logger *slog.Logger, layer, message string, err error, fields map[string]any) {
+	def, _ := apperror.Resolve(err)
+	if fields == nil {
+		fields = make(map[string]any)
+	}
+	if _, ok := fields["error.type"]; !ok {
+		fields["error.type"] = string(def.Kind)
+	}
+	logError(ctx, logger, layer, message, err, fields, def.Notify, string(def.Kind))
+}
+
+// logError writes an ERROR log and tags the active span
+// Must be called directly from an exported Log* function so caller info is correct
+func logError(ctx context.Context, logger *slog.Logger, layer, message string, err error, fields map[string]any, notify bool, defaultType string) {
 	if fields == nil {
 		fields = make(map[string]any)
 	}
 
 	// Add error.notify field to logs
-	fields["error.notify"] = false
+	fields["error.notify"] = notify
 	fields["error"] = err.Error()
 
 	if span, ok := tracer.SpanFromContext(ctx); ok {
 		span.SetTag("error", true)
 		span.SetTag("error.msg", err.Error())
-		span.SetTag("error.notify", false)
+		span.SetTag("error.notify", notify)
 
 		if errorType, ok := fields["error.type"]; ok {
 			span.SetTag("error.type", errorType)
 		} else {
-			span.SetTag("error.type", "expected_error")
+			span.SetTag("error.type", defaultType)
 		}
 	}
 
-	attrs := prepareLogAttrs(ctx, layer, fields, 2)
+	attrs := prepareLogAttrs(ctx, layer, fields, 3)
 	formattedMessage := formatLogMessage(layer, message)
 	logger.ErrorContext(ctx, formattedMessage, attrs...)
 }
@@ -141,10 +145,21 @@ func LogErrorWithTraceNotNotify(ctx context.Context, logger *slog.Logger, layer,
 //
 //	LogSQL(ctx, logger, "INSERT INTO users (name, email) VALUES (?, ?)", []interface{}{"John", "john@example.com"}, time.Millisecond*5, 1, nil)
 //	// Output: [2024-11-05 15:04:05]  [5.00ms]  INSERT INTO users (name, email) VALUES ('John', 'john@example.com')  [1 rows]
-func LogSQL(ctx context.Context, logger *slog.Logger, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error) {
+//
+// attrs are extra key/value pairs appended to the record (e.g. "db.tx_id", id)
+func LogSQL(ctx context.Context, logger *slog.Logger, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error, attrs ...any) {
+	LogSQLAt(ctx, logger, slog.LevelInfo, query, args, duration, rowsAffected, err, attrs...)
+}
+
+// LogSQLAt is LogSQL with the level for successful statements (e.g. WARN for a
+// slow query); failed statements are always logged at ERROR
+func LogSQLAt(ctx context.Context, logger *slog.Logger, level slog.Level, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error, attrs ...any) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	durationMs := fmt.Sprintf("%.2fms", float64(duration.Microseconds())/1000.0)
 
+	// Arguments bound to PII columns are redacted before they reach the message or sql.args
+	args = redact.Default().SQLArgs(query, args)
+
 	// Replace placeholders with actual values
 	formattedQuery := formatSQLWithArgs(query, args)
 
@@ -160,12 +175,12 @@ func LogSQL(ctx context.Context, logger *slog.Logger, query string, args []inter
 	// Convert duration to float64 milliseconds for accurate sub-millisecond values
 	durationMsFloat := float64(duration.Microseconds()) / 1000.0
 
-	attrs := []any{
+	attrs = append([]any{
 		"component", "sql",
 		"sql.query", query,
 		"sql.args", args,
 		"sql.duration_ms", durationMsFloat,
-	}
+	}, attrs...)
 
 	if rowsAffected >= 0 {
 		attrs = append(attrs, "sql.rows_affected", rowsAffected)
@@ -182,51 +197,11 @@ func LogSQL(ctx context.Context, logger *slog.Logger, query string, args []inter
 	}
 
 	if err != nil {
-		attrs = append(attrs, "sql.error", err.Error(), "error", err.Error())
+		// Driver errors can quote a bound value (e.g. the duplicate email)
+		errText := redact.Default().SQLError(query, err)
+		attrs = append(attrs, "sql.error", errText, "error", errText)
 		logger.ErrorContext(ctx, message, attrs...)
 	} else {
-		logger.InfoContext(ctx, message, attrs...)
-	}
-}
-
-// formatSQLWithArgs replaces SQL placeholders with actual values for logging
-func formatSQLWithArgs(query string, args []interface{}) string {
-	if len(args) == 0 {
-		return query
+		logger.Log(ctx, level, message, attrs...)
 	}
-
-	result := query
-	for _, arg := range args {
-		var value string
-		switch v := arg.(type) {
-		case string:
-			value = fmt.Sprintf("'%s'", v)
-		case time.Time:
-			value = fmt.Sprintf("'%s'", v.Format("2006-01-02 15:04:05"))
-		case nil:
-			value = "NULL"
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-			value = fmt.Sprintf("%v", v)
-		case float32, float64:
-			value = fmt.Sprintf("%v", v)
-		case bool:
-			if v {
-				value = "TRUE"
-			} else {
-				value = "FALSE"
-			}
-		default:
-			value = fmt.Sprintf("'%v'", v)
-		}
-
-		// Replace first occurrence of ?
-		for i := 0; i < len(result); i++ {
-			if result[i] == '?' {
-				result = result[:i] + value + result[i+1:]
-				break
-			}
-		}
-	}
-
-	return result
 }
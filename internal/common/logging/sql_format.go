@@ -0,0 +1,164 @@
+package logging
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/sqllex"
+)
+
+// maxValuerDepth bounds unwrapping of pointers and driver.Valuers
+const maxValuerDepth = 8
+
+// formatSQLWithArgs replaces SQL placeholders with MySQL literals for logging
+// Placeholders inside string literals, quoted identifiers and comments are
+// left alone; extra placeholders stay as ? and extra arguments are ignored
+func formatSQLWithArgs(query string, args []interface{}) string {
+	if len(args) == 0 {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16*len(args))
+
+	next := 0
+	for _, tok := range sqllex.Tokens(query) {
+		if tok.Kind == sqllex.Placeholder && next < len(args) {
+			b.WriteString(sqlLiteral(args[next]))
+			next++
+			continue
+		}
+		b.WriteString(tok.Text)
+	}
+	return b.String()
+}
+
+// sqlLiteral renders one argument as a MySQL literal
+// driver.Valuer (sql.NullString, ...) and pointers are unwrapped first, so a
+// NULL Null* value or a nil pointer renders as NULL
+func sqlLiteral(arg interface{}) string {
+	for depth := 0; depth < maxValuerDepth; depth++ {
+		if arg == nil {
+			return "NULL"
+		}
+
+		rv := reflect.ValueOf(arg)
+		if rv.Kind() == reflect.Pointer && rv.IsNil() {
+			return "NULL"
+		}
+
+		if valuer, ok := arg.(driver.Valuer); ok {
+			v, err := valuer.Value()
+			if err != nil {
+				return quoteSQLString(fmt.Sprintf("<invalid %T: %v>", arg, err))
+			}
+			arg = v
+			continue
+		}
+
+		switch v := arg.(type) {
+		case string:
+			return quoteSQLString(v)
+		case []byte:
+			return hexSQLLiteral(v)
+		case time.Time:
+			return timeSQLLiteral(v)
+		case bool:
+			if v {
+				return "TRUE"
+			}
+			return "FALSE"
+		}
+
+		// Named types such as entities.OrderStatus fall through to their kind
+		switch rv.Kind() {
+		case reflect.Pointer:
+			arg = rv.Elem().Interface()
+			continue
+		case reflect.String:
+			return quoteSQLString(rv.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(rv.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return strconv.FormatUint(rv.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			return floatSQLLiteral(rv.Float(), rv.Type().Bits())
+		case reflect.Bool:
+			if rv.Bool() {
+				return "TRUE"
+			}
+			return "FALSE"
+		case reflect.Slice:
+			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				if rv.IsNil() {
+					return "NULL"
+				}
+				return hexSQLLiteral(rv.Bytes())
+			}
+		}
+		return quoteSQLString(fmt.Sprint(arg))
+	}
+	return quoteSQLString(fmt.Sprint(arg))
+}
+
+// quoteSQLString quotes s as a MySQL string literal with backslash escapes,
+// the same escaping go-sql-driver/mysql uses when interpolating parameters
+func quoteSQLString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case 0:
+			b.WriteString(`\0`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\x1a':
+			b.WriteString(`\Z`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// hexSQLLiteral renders bytes as X'...' so binary data stays exact
+func hexSQLLiteral(v []byte) string {
+	if v == nil {
+		return "NULL"
+	}
+	return "X'" + hex.EncodeToString(v) + "'"
+}
+
+// timeSQLLiteral renders a DATETIME literal with fractional seconds and the
+// UTC offset (MySQL 8.0.19+), so the instant is not lost
+func timeSQLLiteral(t time.Time) string {
+	if t.IsZero() {
+		return "'0000-00-00 00:00:00'"
+	}
+	return "'" + t.Format("2006-01-02 15:04:05.999999-07:00") + "'"
+}
+
+// floatSQLLiteral renders a float without losing precision
+// NaN and infinities have no SQL literal and are quoted
+func floatSQLLiteral(f float64, bits int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if strings.ContainsAny(s, "NI") {
+		return quoteSQLString(s)
+	}
+	return s
+}
@@ -0,0 +1,273 @@
+package logging
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// literal is a value read back from rendered SQL by parseLiterals
+type literal struct {
+	kind  string // "string", "hex", "number", "null", "bool"
+	value string
+}
+
+// parseLiterals is an independent MySQL lexer for the tests: it returns the
+// literals of a rendered statement in order and fails on unterminated strings,
+// so a successful parse shows the rendered SQL is well formed
+func parseLiterals(t *testing.T, sqlText string) []literal {
+	t.Helper()
+
+	var out []literal
+	for i := 0; i < len(sqlText); {
+		c := sqlText[i]
+		switch {
+		case c == '\'':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(sqlText) {
+					t.Fatalf("unterminated string literal in %q", sqlText)
+				}
+				c := sqlText[i]
+				if c == '\\' {
+					if i+1 >= len(sqlText) {
+						t.Fatalf("dangling backslash in %q", sqlText)
+					}
+					b.WriteByte(map[byte]byte{'0': 0, 'n': '\n', 'r': '\r', 'Z': '\x1a', '\'': '\'', '"': '"', '\\': '\\'}[sqlText[i+1]])
+					i += 2
+					continue
+				}
+				if c == '\'' {
+					if i+1 < len(sqlText) && sqlText[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(c)
+				i++
+			}
+			out = append(out, literal{"string", b.String()})
+		case (c == 'X' || c == 'x') && i+1 < len(sqlText) && sqlText[i+1] == '\'':
+			end := strings.IndexByte(sqlText[i+2:], '\'')
+			if end < 0 {
+				t.Fatalf("unterminated hex literal in %q", sqlText)
+			}
+			out = append(out, literal{"hex", sqlText[i+2 : i+2+end]})
+			i += end + 3
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			i++
+			for i < len(sqlText) && strings.IndexByte("0123456789.eE+-", sqlText[i]) >= 0 {
+				i++
+			}
+			out = append(out, literal{"number", sqlText[start:i]})
+		case isWordByte(c):
+			start := i
+			for i < len(sqlText) && isWordByte(sqlText[i]) {
+				i++
+			}
+			switch word := strings.ToUpper(sqlText[start:i]); word {
+			case "NULL":
+				out = append(out, literal{"null", ""})
+			case "TRUE", "FALSE":
+				out = append(out, literal{"bool", word})
+			}
+		default:
+			i++
+		}
+	}
+	return out
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type status string
+
+type stringer struct{ s string }
+
+func (s stringer) String() string { return s.s }
+
+func TestFormatSQLWithArgsRoundTrip(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	created := time.Date(2024, 11, 5, 15, 4, 5, 123456000, tokyo)
+	name := "O'Brien"
+	var nilName *string
+
+	tests := []struct {
+		name string
+		arg  interface{}
+		want literal
+	}{
+		{"plain string", "John", literal{"string", "John"}},
+		{"quote", "O'Brien", literal{"string", "O'Brien"}},
+		{"backslash and double quote", `C:\path "x"`, literal{"string", `C:\path "x"`}},
+		{"control characters", "a\nb\rc\x00d\x1ae", literal{"string", "a\nb\rc\x00d\x1ae"}},
+		{"question mark", "why? because?", literal{"string", "why? because?"}},
+		{"injection attempt", `'; DROP TABLE users; -- \`, literal{"string", `'; DROP TABLE users; -- \`}},
+		{"multibyte", "山田 太郎", literal{"string", "山田 太郎"}},
+		{"bytes", []byte{0xde, 0xad, 0xbe, 0xef}, literal{"hex", "deadbeef"}},
+		{"nil bytes", []byte(nil), literal{"null", ""}},
+		{"int", 42, literal{"number", "42"}},
+		{"negative int64", int64(-7), literal{"number", "-7"}},
+		{"uint64", uint64(18446744073709551615), literal{"number", "18446744073709551615"}},
+		{"float", 1299.99, literal{"number", "1299.99"}},
+		{"bool", true, literal{"bool", "TRUE"}},
+		{"nil", nil, literal{"null", ""}},
+		{"pointer", &name, literal{"string", "O'Brien"}},
+		{"nil pointer", nilName, literal{"null", ""}},
+		{"named string type", status("pending"), literal{"string", "pending"}},
+		{"valid NullString", sql.NullString{String: "x'y", Valid: true}, literal{"string", "x'y"}},
+		{"invalid NullString", sql.NullString{}, literal{"null", ""}},
+		{"valid NullInt64", sql.NullInt64{Int64: 9, Valid: true}, literal{"number", "9"}},
+		{"valid NullTime", sql.NullTime{Time: created, Valid: true}, literal{"string", "2024-11-05 15:04:05.123456+09:00"}},
+		{"time keeps zone", created, literal{"string", "2024-11-05 15:04:05.123456+09:00"}},
+		{"fmt.Stringer", stringer{"it's"}, literal{"string", "it's"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := formatSQLWithArgs("SELECT * FROM t WHERE c = ?", []interface{}{tt.arg})
+
+			got := parseLiterals(t, rendered)
+			if len(got) != 1 {
+				t.Fatalf("rendered %q: want 1 literal, got %d (%v)", rendered, len(got), got)
+			}
+			if got[0] != tt.want {
+				t.Fatalf("rendered %q: want %+v, got %+v", rendered, tt.want, got[0])
+			}
+		})
+	}
+}
+
+func TestFormatSQLWithArgsTimeRoundTrip(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", -5*60*60))
+
+	got := parseLiterals(t, formatSQLWithArgs("INSERT INTO t (created_at) VALUES (?)", []interface{}{created}))
+	if len(got) != 1 {
+		t.Fatalf("want 1 literal, got %v", got)
+	}
+
+	parsed, err := time.Parse("2006-01-02 15:04:05.999999-07:00", got[0].value)
+	if err != nil {
+		t.Fatalf("rendered time %q does not parse: %v", got[0].value, err)
+	}
+	if !parsed.Equal(created) {
+		t.Fatalf("want instant %s, got %s", created, parsed)
+	}
+}
+
+func TestFormatSQLWithArgsBytesRoundTrip(t *testing.T) {
+	data := []byte("binary\x00'?\\data")
+
+	got := parseLiterals(t, formatSQLWithArgs("UPDATE t SET b = ?", []interface{}{data}))
+	if len(got) != 1 || got[0].kind != "hex" {
+		t.Fatalf("want 1 hex literal, got %v", got)
+	}
+	decoded, err := hex.DecodeString(got[0].value)
+	if err != nil || string(decoded) != string(data) {
+		t.Fatalf("want %q, got %q (%v)", data, decoded, err)
+	}
+}
+
+func TestFormatSQLWithArgsPlaceholders(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  string
+	}{
+		{
+			name:  "string argument containing placeholder",
+			query: "INSERT INTO users (name, email) VALUES (?, ?)",
+			args:  []interface{}{"what?", "a@example.com"},
+			want:  `INSERT INTO users (name, email) VALUES ('what?', 'a@example.com')`,
+		},
+		{
+			name:  "placeholder inside string literal",
+			query: "SELECT '?' AS q, name FROM users WHERE id = ?",
+			args:  []interface{}{1},
+			want:  "SELECT '?' AS q, name FROM users WHERE id = 1",
+		},
+		{
+			name:  "escaped quote inside literal",
+			query: `SELECT 'it''s?', 'a\'?' FROM t WHERE id = ?`,
+			args:  []interface{}{2},
+			want:  `SELECT 'it''s?', 'a\'?' FROM t WHERE id = 2`,
+		},
+		{
+			name:  "quoted identifier",
+			query: "SELECT `odd?column` FROM t WHERE id = ?",
+			args:  []interface{}{3},
+			want:  "SELECT `odd?column` FROM t WHERE id = 3",
+		},
+		{
+			name:  "comments",
+			query: "SELECT /* id = ? */ name FROM t -- or ?\nWHERE id = ? # and ?",
+			args:  []interface{}{4},
+			want:  "SELECT /* id = ? */ name FROM t -- or ?\nWHERE id = 4 # and ?",
+		},
+		{
+			name:  "double dash without space is an operator",
+			query: "SELECT 1--? FROM t",
+			args:  []interface{}{5},
+			want:  "SELECT 1--5 FROM t",
+		},
+		{
+			name:  "fewer arguments than placeholders",
+			query: "SELECT * FROM t WHERE a = ? AND b = ?",
+			args:  []interface{}{6},
+			want:  "SELECT * FROM t WHERE a = 6 AND b = ?",
+		},
+		{
+			name:  "more arguments than placeholders",
+			query: "SELECT * FROM t WHERE a = ?",
+			args:  []interface{}{7, 8},
+			want:  "SELECT * FROM t WHERE a = 7",
+		},
+		{
+			name:  "no arguments",
+			query: "SELECT * FROM t WHERE a = ?",
+			want:  "SELECT * FROM t WHERE a = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSQLWithArgs(tt.query, tt.args); got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatSQLWithArgsMultipleRoundTrip(t *testing.T) {
+	args := []interface{}{"a'b?", nil, 3.5, []byte("?"), "c\\d"}
+
+	rendered := formatSQLWithArgs("INSERT INTO t (a, b, c, d, e) VALUES (?, ?, ?, ?, ?)", args)
+	got := parseLiterals(t, rendered)
+
+	want := []literal{
+		{"string", "a'b?"},
+		{"null", ""},
+		{"number", strconv.FormatFloat(3.5, 'g', -1, 64)},
+		{"hex", hex.EncodeToString([]byte("?"))},
+		{"string", "c\\d"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("rendered %q: want %d literals, got %v", rendered, len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rendered %q: literal %d: want %+v, got %+v", rendered, i, want[i], got[i])
+		}
+	}
+}
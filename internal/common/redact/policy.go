@@ -0,0 +1,224 @@
+// Package redact is the central PII redaction policy for logs, span tags and
+// problem details
+//
+// Rules map a key to an action. SQL column rules apply to the arguments bound
+// to that column in logged SQL; attribute rules apply to log attributes, span
+// tags and problem extras with exactly that key (e.g. "user.email"), and to
+// log attributes inside groups by their dotted path (e.g. "user.email" for
+// email in group user).
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Action is what happens to a matching value
+type Action string
+
+const (
+	// Mask keeps the first character (and an email's domain): "j***@example.com"
+	Mask Action = "mask"
+	// Hash replaces the value with a keyed SHA-256 prefix, so equal values stay correlatable
+	Hash Action = "hash"
+	// Drop removes the attribute, tag or extra; SQL arguments become "[REDACTED]"
+	Drop Action = "drop"
+)
+
+// dropped is logged in place of a dropped SQL argument, which cannot be removed
+const dropped = "[REDACTED]"
+
+// Policy holds redaction rules; the zero value redacts nothing
+type Policy struct {
+	columns    map[string]Action
+	attributes map[string]Action
+	hashKey    []byte
+}
+
+// NewPolicy builds a policy from rule lists such as "email=hash,name=mask"
+// Column names are matched case-insensitively, attribute keys exactly
+// hash rules require hashKey: an unkeyed SHA-256 of an email is reversed by
+// hashing a list of candidate addresses
+func NewPolicy(columns, attributes, hashKey string) (*Policy, error) {
+	columnRules, err := ParseRules(columns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SQL column rules: %w", err)
+	}
+	attributeRules, err := ParseRules(attributes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attribute rules: %w", err)
+	}
+	if hashKey == "" && (usesHash(columnRules) || usesHash(attributeRules)) {
+		return nil, fmt.Errorf("%s rules require a hash key", Hash)
+	}
+
+	p := &Policy{
+		columns:    make(map[string]Action, len(columnRules)),
+		attributes: attributeRules,
+		hashKey:    []byte(hashKey),
+	}
+	for column, action := range columnRules {
+		p.columns[strings.ToLower(column)] = action
+	}
+	return p, nil
+}
+
+// ParseRules parses a comma-separated list of key=action rules
+func ParseRules(spec string) (map[string]Action, error) {
+	rules := make(map[string]Action)
+	for _, rule := range strings.Split(spec, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		key, action, ok := strings.Cut(rule, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("rule %q must be key=action", rule)
+		}
+		switch a := Action(strings.TrimSpace(action)); a {
+		case Mask, Hash, Drop:
+			rules[key] = a
+		default:
+			return nil, fmt.Errorf("rule %q: action must be %s, %s or %s", rule, Mask, Hash, Drop)
+		}
+	}
+	return rules, nil
+}
+
+// usesHash reports whether any rule hashes its value
+func usesHash(rules map[string]Action) bool {
+	for _, action := range rules {
+		if action == Hash {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPolicy is used by the package-level helpers
+var defaultPolicy atomic.Pointer[Policy]
+
+func init() {
+	defaultPolicy.Store(&Policy{})
+}
+
+// Default returns the process-wide policy
+func Default() *Policy {
+	return defaultPolicy.Load()
+}
+
+// SetDefault replaces the process-wide policy, normally once after config is loaded
+func SetDefault(p *Policy) {
+	defaultPolicy.Store(p)
+}
+
+// apply returns the redacted value, or false when it must be dropped
+func (p *Policy) apply(action Action, v any) (any, bool) {
+	switch action {
+	case Mask:
+		return mask(v), true
+	case Hash:
+		return p.hash(v), true
+	case Drop:
+		return nil, false
+	default:
+		return v, true
+	}
+}
+
+// Attribute redacts the value of an attribute, span tag or problem extra
+// It returns false when the key must be dropped
+func (p *Policy) Attribute(key string, v any) (any, bool) {
+	action, ok := p.attributes[key]
+	if !ok {
+		return v, true
+	}
+	return p.apply(action, v)
+}
+
+// ReplaceAttr redacts log attributes; use it as slog.HandlerOptions.ReplaceAttr
+// Attributes inside groups are matched by their dotted path, so email in
+// group user matches the rule for "user.email"
+// The default policy is read on every call, so SetDefault takes effect immediately
+func ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + a.Key
+	}
+	p := Default()
+	action, ok := p.attributes[key]
+	if !ok {
+		return a
+	}
+	v, keep := p.apply(action, a.Value.Any())
+	if !keep {
+		return slog.Attr{}
+	}
+	return slog.Any(a.Key, v)
+}
+
+// SetTag sets a span tag redacted by the default policy
+func SetTag(span tracer.Span, key string, v any) {
+	if v, keep := Default().Attribute(key, v); keep {
+		span.SetTag(key, v)
+	}
+}
+
+// Map returns a copy of m with values redacted by the default policy
+func Map(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if v, keep := Default().Attribute(k, v); keep {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// mask keeps the first character of a string and the domain of an email
+func mask(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return "***"
+	}
+	if s == "" {
+		return ""
+	}
+	local, domain, isEmail := strings.Cut(s, "@")
+	first := []rune(local)
+	if len(first) == 0 {
+		return "***"
+	}
+	if isEmail {
+		return string(first[0]) + "***@" + domain
+	}
+	return string(first[0]) + "***"
+}
+
+// hash returns "sha256:" and the first 16 hex digits of the keyed hash of v
+func (p *Policy) hash(v any) string {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	mac := hmac.New(sha256.New, p.hashKey)
+	mac.Write([]byte(s))
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,81 @@
+package redact
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewPolicyRequiresHashKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		columns    string
+		attributes string
+		hashKey    string
+		wantErr    bool
+	}{
+		{"mask without key", "email=mask", "user.email=mask", "", false},
+		{"column hash without key", "email=hash", "", "", true},
+		{"attribute hash without key", "", "user.email=hash", "", true},
+		{"hash with key", "email=hash", "user.email=hash", "secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPolicy(tt.columns, tt.attributes, tt.hashKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestReplaceAttrMatchesGroupPath(t *testing.T) {
+	policy, err := NewPolicy("", "user.email=mask,token=drop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := Default()
+	SetDefault(policy)
+	defer SetDefault(previous)
+
+	tests := []struct {
+		name   string
+		groups []string
+		attr   slog.Attr
+		want   slog.Attr
+	}{
+		{"top-level key", nil, slog.String("user.email", "john@example.com"), slog.String("user.email", "j***@example.com")},
+		{"grouped key", []string{"user"}, slog.String("email", "john@example.com"), slog.String("email", "j***@example.com")},
+		{"nested group", []string{"request", "user"}, slog.String("email", "john@example.com"), slog.String("email", "john@example.com")},
+		{"dropped", nil, slog.String("token", "abc"), slog.Attr{}},
+		{"unrelated", []string{"order"}, slog.String("email", "john@example.com"), slog.String("email", "john@example.com")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReplaceAttr(tt.groups, tt.attr); !got.Equal(tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSQLErrorRedactsQuotedValues(t *testing.T) {
+	policy, err := NewPolicy("email=mask", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dup := errors.New("Error 1062 (23000): Duplicate entry 'alice@example.com' for key 'users.email'")
+
+	got := policy.SQLError("INSERT INTO users (name, email) VALUES (?, ?)", dup)
+	if strings.Contains(got, "alice") || !strings.HasPrefix(got, "Error 1062 (23000): Duplicate entry ") {
+		t.Fatalf("want the value redacted and the error number kept, got %q", got)
+	}
+
+	unrelated := "SELECT id FROM orders WHERE id = ?"
+	if got := policy.SQLError(unrelated, dup); got != dup.Error() {
+		t.Fatalf("want error text unchanged for %q, got %q", unrelated, got)
+	}
+}
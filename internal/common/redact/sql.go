@@ -0,0 +1,204 @@
+package redact
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/sqllex"
+)
+
+// SQLArgs returns args with the values bound to redacted columns replaced
+// Columns are found from the query text: "col = ?", "col LIKE ?", "col IN (?, ?)"
+// and the column list of "INSERT INTO t (a, b) VALUES (?, ?)"
+// args is returned unchanged when no placeholder binds a redacted column
+func (p *Policy) SQLArgs(query string, args []interface{}) []interface{} {
+	if len(p.columns) == 0 || len(args) == 0 {
+		return args
+	}
+
+	var out []interface{}
+	for i, column := range placeholderColumns(query) {
+		if i >= len(args) {
+			break
+		}
+		action, ok := p.columns[column]
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = append([]interface{}{}, args...)
+		}
+		v, keep := p.apply(action, args[i])
+		if !keep {
+			v = dropped
+		}
+		out[i] = v
+	}
+
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// RedactsSQL reports whether any argument of query would be redacted
+func (p *Policy) RedactsSQL(query string) bool {
+	if len(p.columns) == 0 {
+		return false
+	}
+	for _, column := range placeholderColumns(query) {
+		if _, ok := p.columns[column]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SQLError returns the text of an error from query, safe to log
+// When query binds a redacted column, everything from the first quote on is
+// replaced, since MySQL quotes the offending value in messages such as
+// "Error 1062 (23000): Duplicate entry 'alice@example.com' for key 'users.email'";
+// the error number and the message prefix are kept
+func (p *Policy) SQLError(query string, err error) string {
+	text := err.Error()
+	if !p.RedactsSQL(query) {
+		return text
+	}
+	if i := strings.IndexAny(text, "'\""); i >= 0 {
+		return text[:i] + dropped
+	}
+	return text
+}
+
+// placeholderColumns returns the lower-cased column bound to each ? in query,
+// or "" where it cannot be determined
+func placeholderColumns(query string) []string {
+	tokens := sqlTokens(query)
+
+	var columns []string
+	var insertColumns []string
+	inValues := false
+	depth, position := 0, 0
+
+	for i, tok := range tokens {
+		upper := strings.ToUpper(tok)
+		switch {
+		case upper == "INTO" && i+2 < len(tokens) && tokens[i+2] == "(":
+			insertColumns = identifierList(tokens[i+3:])
+		case upper == "VALUES":
+			inValues = true
+			depth, position = 0, 0
+			continue
+		}
+
+		if inValues {
+			switch tok {
+			case "(":
+				depth++
+				if depth == 1 {
+					position = 0
+				}
+			case ")":
+				depth--
+				if depth == 0 && (i+1 >= len(tokens) || tokens[i+1] != ",") {
+					inValues = false
+				}
+			case ",":
+				if depth == 1 {
+					position++
+				}
+			}
+		}
+
+		if tok != "?" {
+			continue
+		}
+		switch {
+		case inValues && depth == 1 && position < len(insertColumns):
+			columns = append(columns, insertColumns[position])
+		case inValues:
+			columns = append(columns, "")
+		default:
+			columns = append(columns, columnBefore(tokens[:i]))
+		}
+	}
+	return columns
+}
+
+// skipBeforePlaceholder are the tokens between a column and its placeholder
+var skipBeforePlaceholder = map[string]bool{
+	"=": true, "<": true, ">": true, "<=": true, ">=": true, "<>": true, "!=": true, "<=>": true,
+	"LIKE": true, "NOT": true, "IN": true, "(": true, ",": true, "?": true,
+}
+
+// sqlKeywords are identifiers that end the backward search for a column
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "WHERE": true, "AND": true, "OR": true, "SET": true, "LIMIT": true,
+	"OFFSET": true, "ON": true, "BY": true, "HAVING": true, "VALUES": true, "AS": true,
+}
+
+// columnBefore finds the column compared with a placeholder at the end of tokens
+func columnBefore(tokens []string) string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		tok := tokens[i]
+		if skipBeforePlaceholder[strings.ToUpper(tok)] {
+			continue
+		}
+		if !isIdentifier(tok) || sqlKeywords[strings.ToUpper(tok)] {
+			return ""
+		}
+		return columnName(tok)
+	}
+	return ""
+}
+
+// identifierList reads "a, b, c)" into lower-cased column names
+func identifierList(tokens []string) []string {
+	var names []string
+	for _, tok := range tokens {
+		switch {
+		case tok == ")":
+			return names
+		case tok == ",":
+		case isIdentifier(tok):
+			names = append(names, columnName(tok))
+		default:
+			return names
+		}
+	}
+	return names
+}
+
+// columnName strips backticks and a table qualifier: `u`.`email` -> email
+func columnName(tok string) string {
+	if i := strings.LastIndexByte(tok, '.'); i >= 0 {
+		tok = tok[i+1:]
+	}
+	return strings.ToLower(strings.Trim(tok, "`"))
+}
+
+// isIdentifier reports whether tok is a (possibly qualified or quoted) name
+func isIdentifier(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	r := rune(tok[0])
+	return r == '`' || r == '_' || unicode.IsLetter(r)
+}
+
+// sqlTokens returns the identifiers, placeholders and punctuation of a query
+// Comments are dropped and every string literal becomes "'", so a ? inside
+// them is not a placeholder
+func sqlTokens(query string) []string {
+	var tokens []string
+	for _, tok := range sqllex.Tokens(query) {
+		switch tok.Kind {
+		case sqllex.Space, sqllex.Comment:
+		case sqllex.String:
+			tokens = append(tokens, "'")
+		default:
+			tokens = append(tokens, tok.Text)
+		}
+	}
+	return tokens
+}
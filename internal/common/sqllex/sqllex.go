@@ -0,0 +1,163 @@
+// Package sqllex splits MySQL statements into tokens
+//
+// It is the single place that knows how MySQL quotes strings and identifiers
+// and where comments start and end. SQL logging, PII redaction, query
+// fingerprinting and the migration runner all build on it, so a ? or ; inside
+// a literal or comment is treated the same way everywhere.
+//
+// Tokenizing is lossless: concatenating the Text of every token gives back
+// the input, unterminated literals and comments included.
+package sqllex
+
+import "strings"
+
+// Kind classifies a token
+type Kind int
+
+const (
+	// Space is a run of whitespace
+	Space Kind = iota
+	// Comment is "# ...", "-- ..." (the dashes followed by whitespace) or
+	// "/* ... */"; line comments do not include the newline
+	Comment
+	// String is a quoted literal: '...', "..." or a hex/bit literal X'...', B'...'
+	String
+	// Number is a numeric literal such as 42, 1.5, .5, 1e10 or 0x1F
+	Number
+	// Word is a keyword or identifier, optionally qualified and backtick-quoted:
+	// users, u.email, `order`, `u`.`email`
+	Word
+	// Placeholder is a ? parameter marker
+	Placeholder
+	// Operator is a run of comparison characters: =, <>, !=, <=>, ...
+	Operator
+	// Punct is any other single character: ( ) , ; . * + - ...
+	Punct
+)
+
+// Token is one lexical element of a statement
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// Tokens splits query into tokens
+func Tokens(query string) []Token {
+	var tokens []Token
+	for i := 0; i < len(query); {
+		kind, end := next(query, i)
+		tokens = append(tokens, Token{Kind: kind, Text: query[i:end]})
+		i = end
+	}
+	return tokens
+}
+
+// next returns the kind of the token starting at i and the index after it
+func next(query string, i int) (Kind, int) {
+	c := query[i]
+	switch {
+	case isSpace(c):
+		end := i + 1
+		for end < len(query) && isSpace(query[end]) {
+			end++
+		}
+		return Space, end
+	case c == '\'' || c == '"':
+		return String, SkipQuoted(query, i)
+	case (c == 'x' || c == 'X' || c == 'b' || c == 'B') && i+1 < len(query) && query[i+1] == '\'':
+		return String, SkipQuoted(query, i+1)
+	case c == '#' || isDashComment(query, i):
+		end := strings.IndexByte(query[i:], '\n')
+		if end < 0 {
+			return Comment, len(query)
+		}
+		return Comment, i + end
+	case c == '/' && strings.HasPrefix(query[i:], "/*"):
+		end := strings.Index(query[i+2:], "*/")
+		if end < 0 {
+			return Comment, len(query)
+		}
+		return Comment, i + 2 + end + 2
+	case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+		end := i + 1
+		for end < len(query) && (isWordChar(query[end]) || query[end] == '.') {
+			end++
+		}
+		return Number, end
+	case c == '`' || isWordChar(c):
+		return Word, skipWord(query, i)
+	case c == '?':
+		return Placeholder, i + 1
+	case strings.IndexByte("<>!=", c) >= 0:
+		end := i + 1
+		for end < len(query) && strings.IndexByte("<>!=", query[end]) >= 0 {
+			end++
+		}
+		return Operator, end
+	default:
+		return Punct, i + 1
+	}
+}
+
+// SkipQuoted returns the index just past the quoted string or identifier at i
+// Doubled quotes are understood everywhere, backslash escapes only in strings;
+// an unterminated literal runs to the end of s
+func SkipQuoted(s string, i int) int {
+	quote := s[i]
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// skipWord returns the index after the (qualified) identifier at i
+func skipWord(s string, i int) int {
+	for {
+		if s[i] == '`' {
+			i = SkipQuoted(s, i)
+		} else {
+			for i < len(s) && isWordChar(s[i]) {
+				i++
+			}
+		}
+		// Continue through a qualifier dot: u.email, `u`.`email`
+		if i+1 < len(s) && s[i] == '.' && (s[i+1] == '`' || isWordChar(s[i+1])) {
+			i++
+			continue
+		}
+		return i
+	}
+}
+
+// isDashComment reports whether "--" at i starts a comment; MySQL requires
+// whitespace (or the end of input) after the dashes, so 1--1 is arithmetic
+func isDashComment(s string, i int) bool {
+	return strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || isSpace(s[i+2]))
+}
+
+// isSpace reports whether c is SQL whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isWordChar reports whether c can be part of an unquoted identifier or keyword
+// Bytes of multibyte UTF-8 characters are accepted, as MySQL does
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
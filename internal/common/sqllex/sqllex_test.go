@@ -0,0 +1,103 @@
+package sqllex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokensRoundTrip(t *testing.T) {
+	queries := []string{
+		"SELECT id, email FROM users WHERE id = ?",
+		"SELECT 'it''s', 'a\\'b', \"q\" FROM t -- tail",
+		"SELECT `odd``name`, `u`.`email` FROM t /* c */ WHERE x <=> ?",
+		"SELECT 'unterminated",
+		"/* unterminated comment",
+		"INSERT INTO t VALUES (X'0F', B'01', .5, 1e10);\n# done",
+	}
+
+	for _, query := range queries {
+		var b strings.Builder
+		for _, tok := range Tokens(query) {
+			b.WriteString(tok.Text)
+		}
+		if got := b.String(); got != query {
+			t.Fatalf("want %q, got %q", query, got)
+		}
+	}
+}
+
+func TestTokensKinds(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []Token
+	}{
+		{
+			name:  "placeholder inside string",
+			query: "a = '?' AND b = ?",
+			want: []Token{
+				{Word, "a"}, {Space, " "}, {Operator, "="}, {Space, " "}, {String, "'?'"}, {Space, " "},
+				{Word, "AND"}, {Space, " "}, {Word, "b"}, {Space, " "}, {Operator, "="}, {Space, " "}, {Placeholder, "?"},
+			},
+		},
+		{
+			name:  "escaped and doubled quotes",
+			query: `'a\'b' 'c''d'`,
+			want:  []Token{{String, `'a\'b'`}, {Space, " "}, {String, "'c''d'"}},
+		},
+		{
+			name:  "backslash does not escape in backticks",
+			query: "`a\\`.`b`",
+			want:  []Token{{Word, "`a\\`.`b`"}},
+		},
+		{
+			name:  "qualified identifier",
+			query: "u.email",
+			want:  []Token{{Word, "u.email"}},
+		},
+		{
+			name:  "line comments stop before the newline",
+			query: "-- ?\n# ;\n1",
+			want:  []Token{{Comment, "-- ?"}, {Space, "\n"}, {Comment, "# ;"}, {Space, "\n"}, {Number, "1"}},
+		},
+		{
+			name:  "double dash without space is arithmetic",
+			query: "1--?",
+			want:  []Token{{Number, "1"}, {Punct, "-"}, {Punct, "-"}, {Placeholder, "?"}},
+		},
+		{
+			name:  "double dash at end of input is a comment",
+			query: "1 --",
+			want:  []Token{{Number, "1"}, {Space, " "}, {Comment, "--"}},
+		},
+		{
+			name:  "block comment",
+			query: "/* ; ? */;",
+			want:  []Token{{Comment, "/* ; ? */"}, {Punct, ";"}},
+		},
+		{
+			name:  "hex and bit literals",
+			query: "X'0F' b'01' x",
+			want:  []Token{{String, "X'0F'"}, {Space, " "}, {String, "b'01'"}, {Space, " "}, {Word, "x"}},
+		},
+		{
+			name:  "numbers",
+			query: "1.5 .5 0x1F",
+			want:  []Token{{Number, "1.5"}, {Space, " "}, {Number, ".5"}, {Space, " "}, {Number, "0x1F"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Tokens(tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("token %d: want %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package entities
+
+import "github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+
+// Domain errors returned by repositories and use cases
+// Callers should match them with errors.Is, since they are usually wrapped;
+// their kind decides the HTTP status and whether they alert
+var (
+	// ErrUserNotFound is returned when no user exists with the requested ID
+	ErrUserNotFound = apperror.New(apperror.KindNotFound, "User with the specified ID does not exist")
+	// ErrDuplicateEmail is returned when another user already has the email
+	ErrDuplicateEmail = apperror.New(apperror.KindConflict, "A user with this email already exists")
+	// ErrUserHasOrders is returned when deleting a user that still owns orders
+	ErrUserHasOrders = apperror.New(apperror.KindConflict, "User still has orders and cannot be deleted")
+	// ErrOrderNotFound is returned when no order exists with the requested ID
+	ErrOrderNotFound = apperror.New(apperror.KindNotFound, "Order with the specified ID does not exist")
+)
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// OrderStatus represents the lifecycle state of an order
+type OrderStatus string
+
+// Order statuses matching the orders.status ENUM column
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusCompleted OrderStatus = "completed"
+	OrderStatusCancelled OrderStatus = "cancelled"
+)
+
+// Order represents an order entity placed by a user
+type Order struct {
+	ID          int         `json:"id"`
+	UserID      int         `json:"user_id"`
+	ProductName string      `json:"product_name"`
+	Amount      float64     `json:"amount"`
+	Status      OrderStatus `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
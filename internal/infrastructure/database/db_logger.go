@@ -2,31 +2,124 @@ package database
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
-// LoggingDB wraps sql.DB to automatically log SQL queries in GORM format
-type LoggingDB struct {
-	*sql.DB
-	logger *slog.Logger
+// executor is the query API shared by LoggingDB and LoggingTx, so the
+// repositories run the same code inside and outside a transaction
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*LoggingRows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *LoggingRow
 }
 
-// NewLoggingDB creates a new LoggingDB wrapper
-func NewLoggingDB(db *sql.DB, logger *slog.Logger) *LoggingDB {
-	return &LoggingDB{
-		DB:     db,
-		logger: logger,
+// queryLogger logs statements with LogSQL and records their metrics
+// attrs are appended to every record (e.g. db.tx_id inside a transaction)
+type queryLogger struct {
+	logger  *slog.Logger
+	metrics port.Metrics
+	attrs   []any
+	slow    SlowQueryOptions
+	explain *explainer
+	stats   *QueryStats
+	role    string // db.role span tag, see WithRole
+}
+
+// with returns a copy of l that adds attrs to every record
+func (l queryLogger) with(attrs ...any) queryLogger {
+	l.attrs = append(append([]any{}, l.attrs...), attrs...)
+	return l
+}
+
+// log records one finished statement
+// Slow statements are logged at WARN or ERROR and tagged on the current span;
+// with EXPLAIN enabled, a slow SELECT is logged once its plan is known
+func (l queryLogger) log(ctx context.Context, query string, args []interface{}, duration time.Duration, rowsAffected int64, err error, attrs ...any) {
+	fp := fingerprintOf(query)
+	attrs = append(append([]any{}, l.attrs...), attrs...)
+	attrs = append(attrs, "sql.fingerprint", fp.ID)
+	l.recordMetrics(query, fp, duration, err)
+	if l.stats != nil {
+		l.stats.record(fp, duration, rowsAffected, err)
+	}
+	if l.role != "" {
+		if span, ok := tracer.SpanFromContext(ctx); ok {
+			span.SetTag("db.role", l.role)
+		}
+	}
+
+	level, threshold := l.slow.level(duration)
+	if threshold == "" {
+		logging.LogSQLAt(ctx, l.logger, level, query, args, duration, rowsAffected, err, attrs...)
+		return
+	}
+
+	attrs = append(attrs, "sql.slow", threshold)
+	l.metrics.Incr("sql.query.slow", "sql.operation:"+sqlOperation(query), "sql.fingerprint:"+fp.ID, "threshold:"+threshold)
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag("sql.slow", threshold)
+		span.SetTag("sql.duration_ms", float64(duration.Microseconds())/1000.0)
+	}
+
+	// Plans embed the bound values in attached_condition, so queries with
+	// redacted arguments are not explained
+	if l.explain != nil && err == nil && sqlOperation(query) == "select" && !redact.Default().RedactsSQL(query) {
+		started := l.explain.run(ctx, query, args, func(plan json.RawMessage, explainErr error) {
+			if explainErr != nil {
+				attrs = append(attrs, "sql.explain_error", explainErr.Error())
+			} else if plan != nil {
+				attrs = append(attrs, "sql.explain", plan)
+			}
+			logging.LogSQLAt(ctx, l.logger, level, query, args, duration, rowsAffected, err, attrs...)
+		})
+		if started {
+			return
+		}
 	}
+
+	logging.LogSQLAt(ctx, l.logger, level, query, args, duration, rowsAffected, err, attrs...)
 }
 
-// ExecContext wraps sql.DB.ExecContext with automatic logging
-func (db *LoggingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+// recordMetrics emits SQL latency and error metrics tagged by statement type,
+// fingerprint and db.role
+func (l queryLogger) recordMetrics(query string, fp fingerprint, duration time.Duration, err error) {
+	tags := []string{"sql.operation:" + sqlOperation(query), "sql.fingerprint:" + fp.ID}
+	if l.role != "" {
+		tags = append(tags, "db.role:"+l.role)
+	}
+	if err != nil {
+		l.metrics.Incr("sql.query.errors", tags...)
+		tags = append(tags, "status:error")
+	} else {
+		tags = append(tags, "status:ok")
+	}
+	l.metrics.Histogram("sql.query.duration", float64(duration.Microseconds())/1000.0, tags...)
+}
+
+// sqlOperation returns the lower-cased leading keyword (select, insert, ...)
+func sqlOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(fields[0])
+}
+
+// exec runs and logs a statement that returns no rows
+func (l queryLogger) exec(ctx context.Context, query string, args []interface{}, run func() (sql.Result, error), attrs ...any) (sql.Result, error) {
 	startTime := time.Now()
-	result, err := db.DB.ExecContext(ctx, query, args...)
+	result, err := run()
 	duration := time.Since(startTime)
 
 	var rowsAffected int64 = -1
@@ -34,31 +127,277 @@ func (db *LoggingDB) ExecContext(ctx context.Context, query string, args ...inte
 		rowsAffected, _ = result.RowsAffected()
 	}
 
-	logging.LogSQL(ctx, db.logger, query, args, duration, rowsAffected, err)
-
+	l.log(ctx, query, args, duration, rowsAffected, err, attrs...)
 	return result, err
 }
 
-// QueryContext wraps sql.DB.QueryContext with automatic logging
-func (db *LoggingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+// query runs a statement returning rows; it is logged when the rows are closed
+// or exhausted, with the number of rows scanned and the final error
+func (l queryLogger) query(ctx context.Context, query string, args []interface{}, run func() (*sql.Rows, error), attrs ...any) (*LoggingRows, error) {
 	startTime := time.Now()
-	rows, err := db.DB.QueryContext(ctx, query, args...)
-	duration := time.Since(startTime)
+	rows, err := run()
+	if err != nil {
+		l.log(ctx, query, args, time.Since(startTime), -1, err, attrs...)
+		return nil, err
+	}
+	return &LoggingRows{
+		Rows: rows,
+		done: func(count int64, err error) {
+			l.log(ctx, query, args, time.Since(startTime), count, err, attrs...)
+		},
+	}, nil
+}
+
+// queryRow runs a single-row statement; it is logged when the row is scanned
+func (l queryLogger) queryRow(ctx context.Context, query string, args []interface{}, run func() *sql.Row, attrs ...any) *LoggingRow {
+	startTime := time.Now()
+	return &LoggingRow{
+		row: run(),
+		done: func(count int64, err error) {
+			l.log(ctx, query, args, time.Since(startTime), count, err, attrs...)
+		},
+	}
+}
+
+// prepare prepares a statement, logging only a failed prepare; every
+// execution of the statement is logged with sql.prepared=true
+func (l queryLogger) prepare(ctx context.Context, query string, run func() (*sql.Stmt, error)) (*LoggingStmt, error) {
+	startTime := time.Now()
+	stmt, err := run()
+	if err != nil {
+		l.log(ctx, query, nil, time.Since(startTime), -1, err, "sql.prepare", true)
+		return nil, err
+	}
+	return &LoggingStmt{Stmt: stmt, log: l, query: query}, nil
+}
+
+// LoggingDB wraps sql.DB to automatically log SQL queries in GORM format
+// and record SQL latency metrics
+// Every way of running SQL is covered: plain and prepared statements,
+// transactions, and the context-less Exec/Query/QueryRow/Prepare/Begin
+type LoggingDB struct {
+	*sql.DB
+	log queryLogger
+}
 
-	// For SELECT queries, we don't know rows count until scanning
-	logging.LogSQL(ctx, db.logger, query, args, duration, -1, err)
+// NewLoggingDB creates a new LoggingDB wrapper
+func NewLoggingDB(db *sql.DB, logger *slog.Logger, metrics port.Metrics, opts ...Option) *LoggingDB {
+	loggingDB := &LoggingDB{
+		DB:  db,
+		log: queryLogger{logger: logger, metrics: metrics},
+	}
+	for _, opt := range opts {
+		opt(loggingDB)
+	}
+	return loggingDB
+}
+
+// ExecContext wraps sql.DB.ExecContext with automatic logging
+func (db *LoggingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.log.exec(ctx, query, args, func() (sql.Result, error) {
+		return db.DB.ExecContext(ctx, query, args...)
+	})
+}
+
+// Exec wraps sql.DB.Exec with automatic logging
+func (db *LoggingDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
 
-	return rows, err
+// QueryContext wraps sql.DB.QueryContext with automatic logging
+// The query is logged once the returned rows are closed or exhausted
+func (db *LoggingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*LoggingRows, error) {
+	return db.log.query(ctx, query, args, func() (*sql.Rows, error) {
+		return db.DB.QueryContext(ctx, query, args...)
+	})
+}
+
+// Query wraps sql.DB.Query with automatic logging
+func (db *LoggingDB) Query(query string, args ...interface{}) (*LoggingRows, error) {
+	return db.QueryContext(context.Background(), query, args...)
 }
 
 // QueryRowContext wraps sql.DB.QueryRowContext with automatic logging
-func (db *LoggingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+// The query is logged once the row is scanned, when its error is known
+func (db *LoggingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *LoggingRow {
+	return db.log.queryRow(ctx, query, args, func() *sql.Row {
+		return db.DB.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// QueryRow wraps sql.DB.QueryRow with automatic logging
+func (db *LoggingDB) QueryRow(query string, args ...interface{}) *LoggingRow {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext wraps sql.DB.PrepareContext; executions of the statement are logged
+func (db *LoggingDB) PrepareContext(ctx context.Context, query string) (*LoggingStmt, error) {
+	return db.log.prepare(ctx, query, func() (*sql.Stmt, error) {
+		return db.DB.PrepareContext(ctx, query)
+	})
+}
+
+// Prepare wraps sql.DB.Prepare; executions of the statement are logged
+func (db *LoggingDB) Prepare(query string) (*LoggingStmt, error) {
+	return db.PrepareContext(context.Background(), query)
+}
+
+// LoggingTx wraps sql.Tx like LoggingDB wraps sql.DB
+// Every statement, including BEGIN/COMMIT/ROLLBACK, is logged with db.tx_id
+// so the statements of one transaction can be grouped in the logs
+type LoggingTx struct {
+	*sql.Tx
+	log queryLogger
+	id  string
+}
+
+// BeginTx starts a transaction and logs BEGIN
+func (db *LoggingDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*LoggingTx, error) {
+	id := newTxID()
+	log := db.log.with("db.tx_id", id)
+
 	startTime := time.Now()
-	row := db.DB.QueryRowContext(ctx, query, args...)
-	duration := time.Since(startTime)
+	tx, err := db.DB.BeginTx(ctx, opts)
+	log.log(ctx, "BEGIN", nil, time.Since(startTime), -1, err)
 
-	// For QueryRow, we log without error check (error is checked on Scan)
-	logging.LogSQL(ctx, db.logger, query, args, duration, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LoggingTx{Tx: tx, log: log, id: id}, nil
+}
+
+// Begin starts a transaction and logs BEGIN
+func (db *LoggingDB) Begin() (*LoggingTx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// newTxID returns a short random ID for correlating a transaction's statements
+func newTxID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ID returns the transaction ID used in log attributes
+func (tx *LoggingTx) ID() string {
+	return tx.id
+}
+
+// ExecContext wraps sql.Tx.ExecContext with automatic logging
+func (tx *LoggingTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.log.exec(ctx, query, args, func() (sql.Result, error) {
+		return tx.Tx.ExecContext(ctx, query, args...)
+	})
+}
+
+// Exec wraps sql.Tx.Exec with automatic logging
+func (tx *LoggingTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext wraps sql.Tx.QueryContext with automatic logging
+func (tx *LoggingTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*LoggingRows, error) {
+	return tx.log.query(ctx, query, args, func() (*sql.Rows, error) {
+		return tx.Tx.QueryContext(ctx, query, args...)
+	})
+}
+
+// Query wraps sql.Tx.Query with automatic logging
+func (tx *LoggingTx) Query(query string, args ...interface{}) (*LoggingRows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext wraps sql.Tx.QueryRowContext with automatic logging
+func (tx *LoggingTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *LoggingRow {
+	return tx.log.queryRow(ctx, query, args, func() *sql.Row {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	})
+}
+
+// QueryRow wraps sql.Tx.QueryRow with automatic logging
+func (tx *LoggingTx) QueryRow(query string, args ...interface{}) *LoggingRow {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+// PrepareContext wraps sql.Tx.PrepareContext; executions of the statement are logged
+func (tx *LoggingTx) PrepareContext(ctx context.Context, query string) (*LoggingStmt, error) {
+	return tx.log.prepare(ctx, query, func() (*sql.Stmt, error) {
+		return tx.Tx.PrepareContext(ctx, query)
+	})
+}
+
+// Prepare wraps sql.Tx.Prepare; executions of the statement are logged
+func (tx *LoggingTx) Prepare(query string) (*LoggingStmt, error) {
+	return tx.PrepareContext(context.Background(), query)
+}
+
+// StmtContext returns stmt bound to the transaction, logged with db.tx_id
+func (tx *LoggingTx) StmtContext(ctx context.Context, stmt *LoggingStmt) *LoggingStmt {
+	return &LoggingStmt{Stmt: tx.Tx.StmtContext(ctx, stmt.Stmt), log: tx.log, query: stmt.query}
+}
+
+// Stmt returns stmt bound to the transaction, logged with db.tx_id
+func (tx *LoggingTx) Stmt(stmt *LoggingStmt) *LoggingStmt {
+	return tx.StmtContext(context.Background(), stmt)
+}
+
+// Commit commits the transaction and logs COMMIT
+// ctx is only used to correlate the log with the current trace
+func (tx *LoggingTx) Commit(ctx context.Context) error {
+	startTime := time.Now()
+	err := tx.Tx.Commit()
+	tx.log.log(ctx, "COMMIT", nil, time.Since(startTime), -1, err)
+	return err
+}
+
+// Rollback rolls the transaction back and logs ROLLBACK
+// ctx is only used to correlate the log with the current trace
+func (tx *LoggingTx) Rollback(ctx context.Context) error {
+	startTime := time.Now()
+	err := tx.Tx.Rollback()
+	tx.log.log(ctx, "ROLLBACK", nil, time.Since(startTime), -1, err)
+	return err
+}
+
+// LoggingStmt wraps sql.Stmt; each execution is logged with the prepared query
+type LoggingStmt struct {
+	*sql.Stmt
+	log   queryLogger
+	query string
+}
+
+// ExecContext wraps sql.Stmt.ExecContext with automatic logging
+func (s *LoggingStmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	return s.log.exec(ctx, s.query, args, func() (sql.Result, error) {
+		return s.Stmt.ExecContext(ctx, args...)
+	}, "sql.prepared", true)
+}
+
+// Exec wraps sql.Stmt.Exec with automatic logging
+func (s *LoggingStmt) Exec(args ...interface{}) (sql.Result, error) {
+	return s.ExecContext(context.Background(), args...)
+}
+
+// QueryContext wraps sql.Stmt.QueryContext with automatic logging
+func (s *LoggingStmt) QueryContext(ctx context.Context, args ...interface{}) (*LoggingRows, error) {
+	return s.log.query(ctx, s.query, args, func() (*sql.Rows, error) {
+		return s.Stmt.QueryContext(ctx, args...)
+	}, "sql.prepared", true)
+}
+
+// Query wraps sql.Stmt.Query with automatic logging
+func (s *LoggingStmt) Query(args ...interface{}) (*LoggingRows, error) {
+	return s.QueryContext(context.Background(), args...)
+}
+
+// QueryRowContext wraps sql.Stmt.QueryRowContext with automatic logging
+func (s *LoggingStmt) QueryRowContext(ctx context.Context, args ...interface{}) *LoggingRow {
+	return s.log.queryRow(ctx, s.query, args, func() *sql.Row {
+		return s.Stmt.QueryRowContext(ctx, args...)
+	}, "sql.prepared", true)
+}
 
-	return row
+// QueryRow wraps sql.Stmt.QueryRow with automatic logging
+func (s *LoggingStmt) QueryRow(args ...interface{}) *LoggingRow {
+	return s.QueryRowContext(context.Background(), args...)
 }
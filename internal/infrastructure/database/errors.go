@@ -0,0 +1,21 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL server error numbers mapped to domain errors
+// See: https://dev.mysql.com/doc/mysql-errors/8.0/en/server-error-reference.html
+const (
+	mysqlErrDuplicateEntry  = 1062 // ER_DUP_ENTRY
+	mysqlErrRowIsReferenced = 1451 // ER_ROW_IS_REFERENCED_2
+	mysqlErrNoReferencedRow = 1452 // ER_NO_REFERENCED_ROW_2
+)
+
+// isMySQLError reports whether err is a MySQL server error with the given number
+func isMySQLError(err error, number uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == number
+}
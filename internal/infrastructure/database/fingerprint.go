@@ -0,0 +1,141 @@
+package database
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/sqllex"
+)
+
+// maxCachedFingerprints bounds the query -> fingerprint cache; repository
+// queries are a small fixed set, so the cache only fills up with generated SQL
+const maxCachedFingerprints = 4096
+
+// fingerprint identifies a normalized query
+type fingerprint struct {
+	ID    string // short stable hash of Query, used as a metric tag
+	Query string // normalized text
+}
+
+var (
+	fingerprintCacheMu sync.RWMutex
+	fingerprintCache   = make(map[string]fingerprint)
+)
+
+// fingerprintOf returns the cached fingerprint of query
+func fingerprintOf(query string) fingerprint {
+	fingerprintCacheMu.RLock()
+	fp, ok := fingerprintCache[query]
+	fingerprintCacheMu.RUnlock()
+	if ok {
+		return fp
+	}
+
+	normalized := normalizeQuery(query)
+	h := fnv.New64a()
+	h.Write([]byte(normalized))
+	fp = fingerprint{ID: strconv.FormatUint(h.Sum64(), 16), Query: normalized}
+
+	fingerprintCacheMu.Lock()
+	if len(fingerprintCache) < maxCachedFingerprints {
+		fingerprintCache[query] = fp
+	}
+	fingerprintCacheMu.Unlock()
+	return fp
+}
+
+// normalizeQuery reduces a query to its shape, so executions that differ only
+// in literals, whitespace, comments or list lengths share one fingerprint
+//
+//	SELECT * FROM t WHERE id IN (1, 2, 3) AND name = 'x'  ->  select * from t where id in (?+) and name = ?
+//	INSERT INTO t (a, b) VALUES (?, ?), (?, ?)            ->  insert into t (a, b) values (?+)
+func normalizeQuery(query string) string {
+	tokens := fingerprintTokens(query)
+	tokens = collapseLists(tokens)
+
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && tok != "," && tok != ")" && tokens[i-1] != "(" {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+// fingerprintTokens lower-cases words and replaces literals with ?
+// Comments are dropped
+func fingerprintTokens(query string) []string {
+	var tokens []string
+	for _, tok := range sqllex.Tokens(query) {
+		switch tok.Kind {
+		case sqllex.Space, sqllex.Comment:
+		case sqllex.String, sqllex.Number:
+			tokens = append(tokens, "?")
+		case sqllex.Word:
+			tokens = append(tokens, strings.ToLower(tok.Text))
+		default:
+			tokens = append(tokens, tok.Text)
+		}
+	}
+	return tokens
+}
+
+// collapseLists turns "in (?, ?, ...)" into "in (?+)" and the tuples after
+// "values" into a single "(?+)"
+func collapseLists(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		out = append(out, tok)
+		if (tok != "in" && tok != "values") || i+1 >= len(tokens) || tokens[i+1] != "(" {
+			continue
+		}
+
+		// Consume placeholder-only tuples separated by commas
+		j := i + 1
+		collapsed := false
+		for {
+			end, ok := placeholderTuple(tokens, j)
+			if !ok {
+				break
+			}
+			collapsed = true
+			j = end
+			if tok != "values" || j >= len(tokens) || tokens[j] != "," || j+1 >= len(tokens) || tokens[j+1] != "(" {
+				break
+			}
+			j++
+		}
+		if collapsed {
+			out = append(out, "(", "?+", ")")
+			i = j - 1
+		}
+	}
+	return out
+}
+
+// placeholderTuple reports whether tokens[start:] begins with "(?, ?, ...)"
+// and returns the index after its closing parenthesis
+func placeholderTuple(tokens []string, start int) (int, bool) {
+	if start >= len(tokens) || tokens[start] != "(" {
+		return 0, false
+	}
+	i := start + 1
+	for i < len(tokens) {
+		if tokens[i] != "?" {
+			return 0, false
+		}
+		i++
+		if i < len(tokens) && tokens[i] == ")" {
+			return i + 1, true
+		}
+		if i >= len(tokens) || tokens[i] != "," {
+			return 0, false
+		}
+		i++
+	}
+	return 0, false
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "literals and whitespace",
+			query: "SELECT  *\n FROM users WHERE id = 42 AND email = 'a@example.com'",
+			want:  "select * from users where id = ? and email = ?",
+		},
+		{
+			name:  "placeholders",
+			query: "SELECT id FROM users WHERE id = ?",
+			want:  "select id from users where id = ?",
+		},
+		{
+			name:  "comments",
+			query: "SELECT /* hint */ id FROM t -- trailing\nWHERE a = 1 # more",
+			want:  "select id from t where a = ?",
+		},
+		{
+			name:  "in list",
+			query: "SELECT id FROM t WHERE id IN (1, 2, 3)",
+			want:  "select id from t where id in (?+)",
+		},
+		{
+			name:  "in list of placeholders",
+			query: "SELECT id FROM t WHERE id IN (?,?)",
+			want:  "select id from t where id in (?+)",
+		},
+		{
+			name:  "multi-row values",
+			query: "INSERT INTO t (a, b) VALUES (?, ?), (?, ?), (?, ?)",
+			want:  "insert into t (a, b) values (?+)",
+		},
+		{
+			name:  "quoted question mark is a literal",
+			query: "SELECT '?;' FROM t",
+			want:  "select ? from t",
+		},
+		{
+			name:  "backtick identifiers keep their name",
+			query: "SELECT `Order`.`ID` FROM `Order`",
+			want:  "select `order`.`id` from `order`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeQuery(tt.query); got != tt.want {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeQuerySameShapeSameFingerprint(t *testing.T) {
+	a := fingerprintOf("SELECT * FROM t WHERE id IN (1, 2)")
+	b := fingerprintOf("select *   from t where id in (3,4,5,6)")
+	if a != b {
+		t.Fatalf("want equal fingerprints, got %v and %v", a, b)
+	}
+}
+
+func TestCollapseLists(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   []string
+	}{
+		{
+			name:   "single in list",
+			tokens: []string{"in", "(", "?", ",", "?", ")"},
+			want:   []string{"in", "(", "?+", ")"},
+		},
+		{
+			name:   "values tuples",
+			tokens: []string{"values", "(", "?", ")", ",", "(", "?", ")"},
+			want:   []string{"values", "(", "?+", ")"},
+		},
+		{
+			name:   "in takes one tuple only",
+			tokens: []string{"in", "(", "?", ")", ",", "(", "?", ")"},
+			want:   []string{"in", "(", "?+", ")", ",", "(", "?", ")"},
+		},
+		{
+			name:   "subquery is kept",
+			tokens: []string{"in", "(", "select", "id", "from", "t", ")"},
+			want:   []string{"in", "(", "select", "id", "from", "t", ")"},
+		},
+		{
+			name:   "mixed tuple is kept",
+			tokens: []string{"in", "(", "?", ",", "a", ")"},
+			want:   []string{"in", "(", "?", ",", "a", ")"},
+		},
+		{
+			name:   "unclosed tuple is kept",
+			tokens: []string{"values", "(", "?", ","},
+			want:   []string{"values", "(", "?", ","},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collapseLists(tt.tokens); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// HealthCheck implements port.HealthChecker by pinging MySQL
+type HealthCheck struct {
+	db       *sql.DB
+	timeout  time.Duration
+	name     string
+	critical bool
+}
+
+// NewHealthCheck creates a new MySQL health check with the given timeout
+func NewHealthCheck(db *sql.DB, timeout time.Duration) *HealthCheck {
+	return &HealthCheck{
+		db:       db,
+		timeout:  timeout,
+		name:     "mysql",
+		critical: true,
+	}
+}
+
+// NewReplicaHealthCheck creates a health check for a read replica
+// A failing replica only degrades readiness, since reads fall back to the primary
+func NewReplicaHealthCheck(name string, db *sql.DB, timeout time.Duration) *HealthCheck {
+	return &HealthCheck{
+		db:      db,
+		timeout: timeout,
+		name:    name,
+	}
+}
+
+// Name returns the component name reported by the readiness probe
+func (h *HealthCheck) Name() string {
+	return h.name
+}
+
+// Critical reports whether the API can serve traffic without this database
+func (h *HealthCheck) Critical() bool {
+	return h.critical
+}
+
+// Check pings MySQL, acquiring a pooled connection if necessary
+func (h *HealthCheck) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	return h.db.PingContext(ctx)
+}
@@ -0,0 +1,373 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/sqllex"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// migrationsTable records which migration versions have been applied
+const migrationsTable = "schema_migrations"
+
+// migrationLockTimeout is how long Up and Down wait for a concurrent migrate
+// run to release the schema_migrations lock
+const migrationLockTimeout = 5 * time.Minute
+
+// migrationFilePattern matches <version>_<name>.<up|down>.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back versioned migrations, recording applied
+// versions in schema_migrations
+// Every statement goes through LoggingDB, so it is logged by LogSQL and
+// measured like application queries
+type Migrator struct {
+	db         *LoggingDB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// NewMigrator creates a Migrator for the migrations found in source
+func NewMigrator(db *sql.DB, source fs.FS, logger *slog.Logger, metrics port.Metrics) (*Migrator, error) {
+	migrations, err := LoadMigrations(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         NewLoggingDB(db, logger, metrics),
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// LoadMigrations reads and pairs the migration files in source, ordered by version
+// Every version must have both an up and a down file
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q: want <version>_<name>.<up|down>.sql", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		data, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d_%s must have non-empty up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// ensureTable creates schema_migrations if it does not exist
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	query := "CREATE TABLE IF NOT EXISTS " + migrationsTable + ` (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`
+
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create %s: %w", migrationsTable, err)
+	}
+	return nil
+}
+
+// applied returns the applied versions and when they were applied
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", migrationsTable, err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", migrationsTable, err)
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "migration.status")
+	defer span.Finish()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns how many ran
+// It stops at the first failure; earlier migrations stay applied
+// Concurrent runs are serialized by withLock, so each version is applied once
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "migration.up")
+	defer span.Finish()
+
+	count := 0
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, migration, "up"); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	span.SetTag("migration.count", count)
+	return count, err
+}
+
+// Down rolls back the most recently applied steps migrations and returns how many ran
+// Concurrent runs are serialized by withLock
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "migration.down")
+	defer span.Finish()
+
+	count := 0
+	err := m.withLock(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.run(ctx, migration, "down"); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	span.SetTag("migration.count", count)
+	return count, err
+}
+
+// withLock runs fn while holding the MySQL named lock "schema_migrations"
+// A named lock belongs to a session, so GET_LOCK and RELEASE_LOCK run on one
+// dedicated connection; if the release fails, the lock is freed when that
+// connection closes
+func (m *Migrator) withLock(ctx context.Context, fn func() error) (err error) {
+	conn, err := m.db.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	// GET_LOCK returns 1 when acquired, 0 on timeout and NULL on error
+	var acquired sql.NullInt64
+	lockQuery := "SELECT GET_LOCK(?, ?)"
+	lockArgs := []interface{}{migrationsTable, int(migrationLockTimeout.Seconds())}
+	// SQL automatically logged by queryLogger
+	row := m.db.log.queryRow(ctx, lockQuery, lockArgs, func() *sql.Row {
+		return conn.QueryRowContext(ctx, lockQuery, lockArgs...)
+	})
+	if err := row.Scan(&acquired); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("failed to acquire migration lock: another migration is still running after %s", migrationLockTimeout)
+	}
+
+	defer func() {
+		// Release even if ctx was canceled while fn ran
+		releaseCtx := context.WithoutCancel(ctx)
+		var released sql.NullInt64
+		releaseQuery := "SELECT RELEASE_LOCK(?)"
+		releaseArgs := []interface{}{migrationsTable}
+		row := m.db.log.queryRow(releaseCtx, releaseQuery, releaseArgs, func() *sql.Row {
+			return conn.QueryRowContext(releaseCtx, releaseQuery, releaseArgs...)
+		})
+		if releaseErr := row.Scan(&released); releaseErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", releaseErr)
+		}
+	}()
+
+	return fn()
+}
+
+// run executes one direction of a migration statement by statement and records the result
+// MySQL commits DDL implicitly, so a failure part-way leaves earlier statements applied
+// and the version unrecorded; migrations should use IF [NOT] EXISTS to be re-runnable
+func (m *Migrator) run(ctx context.Context, migration Migration, direction string) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "migration."+direction+".version")
+	defer span.Finish()
+
+	span.SetTag("migration.version", migration.Version)
+	span.SetTag("migration.name", migration.Name)
+	span.SetTag("migration.direction", direction)
+
+	fields := map[string]any{
+		"migration.version":   migration.Version,
+		"migration.name":      migration.Name,
+		"migration.direction": direction,
+	}
+
+	script := migration.Up
+	if direction == "down" {
+		script = migration.Down
+	}
+
+	logging.LogWithTrace(ctx, m.logger, "migration", "Applying migration", fields)
+	startTime := time.Now()
+
+	statements := splitStatements(script)
+	span.SetTag("migration.statements", len(statements))
+	for i, statement := range statements {
+		// SQL automatically logged by LoggingDB
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			fields["migration.statement"] = i + 1
+			logging.LogErrorWithTrace(ctx, m.logger, "migration", "Migration failed", err, fields)
+			return fmt.Errorf("migration %d_%s %s failed at statement %d: %w", migration.Version, migration.Name, direction, i+1, err)
+		}
+	}
+
+	var err error
+	if direction == "up" {
+		_, err = m.db.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, name) VALUES (?, ?)", migration.Version, migration.Name)
+	} else {
+		_, err = m.db.ExecContext(ctx, "DELETE FROM "+migrationsTable+" WHERE version = ?", migration.Version)
+	}
+	if err != nil {
+		logging.LogErrorWithTrace(ctx, m.logger, "migration", "Failed to record migration", err, fields)
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	fields["migration.duration_ms"] = float64(time.Since(startTime).Microseconds()) / 1000.0
+	logging.LogWithTrace(ctx, m.logger, "migration", "Migration applied", fields)
+	return nil
+}
+
+// splitStatements splits a script on semicolons that are not inside quotes,
+// backticks or comments, dropping empty statements
+func splitStatements(script string) []string {
+	var statements []string
+	var current []sqllex.Token
+
+	flush := func() {
+		var b strings.Builder
+		for _, tok := range current {
+			b.WriteString(tok.Text)
+		}
+		if s := strings.TrimSpace(b.String()); s != "" && !onlyComments(current) {
+			statements = append(statements, s)
+		}
+		current = current[:0]
+	}
+
+	for _, tok := range sqllex.Tokens(script) {
+		if tok.Kind == sqllex.Punct && tok.Text == ";" {
+			flush()
+			continue
+		}
+		current = append(current, tok)
+	}
+	flush()
+
+	return statements
+}
+
+// onlyComments reports whether a statement contains nothing but comments
+func onlyComments(tokens []sqllex.Token) bool {
+	for _, tok := range tokens {
+		if tok.Kind != sqllex.Space && tok.Kind != sqllex.Comment {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "two statements",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name:   "semicolons in literals and comments",
+			script: "INSERT INTO a VALUES ('x;y', `c;d`); -- next;\n/* ; */ SELECT 1",
+			want:   []string{"INSERT INTO a VALUES ('x;y', `c;d`)", "-- next;\n/* ; */ SELECT 1"},
+		},
+		{
+			name:   "comment-only statements are dropped",
+			script: "-- header\n;\n# note\nSELECT 1;\n/* footer */",
+			want:   []string{"# note\nSELECT 1"},
+		},
+		{
+			name:   "empty",
+			script: " ;\n; ",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// OrderRepository implements port.OrderRepository for MySQL
+type OrderRepository struct {
+	db executor
+}
+
+// NewOrderRepository creates a new OrderRepository
+func NewOrderRepository(db *sql.DB, logger *slog.Logger, metrics port.Metrics, opts ...Option) *OrderRepository {
+	return &OrderRepository{
+		db: NewLoggingDB(db, logger, metrics, opts...),
+	}
+}
+
+// Create creates a new order
+func (r *OrderRepository) Create(ctx context.Context, order *entities.Order) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.create_order")
+	defer span.Finish()
+
+	span.SetTag("user.id", order.UserID)
+
+	query := "INSERT INTO orders (user_id, product_name, amount, status, created_at) VALUES (?, ?, ?, ?, ?)"
+
+	// SQL automatically logged by LoggingDB
+	result, err := r.db.ExecContext(ctx, query, order.UserID, order.ProductName, order.Amount, string(order.Status), order.CreatedAt)
+	if isMySQLError(err, mysqlErrNoReferencedRow) {
+		return fmt.Errorf("failed to insert order: %w", entities.ErrUserNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	order.ID = int(id)
+	return nil
+}
+
+// InsertIfAbsent inserts an order with its explicit ID and leaves an existing
+// order with that ID untouched
+// The no-op ON DUPLICATE KEY UPDATE only absorbs the key clash, unlike INSERT
+// IGNORE, which would also turn a missing user into a warning
+func (r *OrderRepository) InsertIfAbsent(ctx context.Context, order *entities.Order) (bool, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.insert_order_if_absent")
+	defer span.Finish()
+
+	span.SetTag("order.id", order.ID)
+	span.SetTag("user.id", order.UserID)
+
+	query := "INSERT INTO orders (id, user_id, product_name, amount, status, created_at) VALUES (?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE id = id"
+
+	// SQL automatically logged by LoggingDB
+	result, err := r.db.ExecContext(ctx, query, order.ID, order.UserID, order.ProductName, order.Amount, string(order.Status), order.CreatedAt)
+	if isMySQLError(err, mysqlErrNoReferencedRow) {
+		return false, fmt.Errorf("failed to insert order: %w", entities.ErrUserNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert order: %w", err)
+	}
+
+	// 1 for an insert, 0 when the ID already existed
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	span.SetTag("order.inserted", affected > 0)
+	return affected > 0, nil
+}
+
+// FindByID finds an order by ID
+func (r *OrderRepository) FindByID(ctx context.Context, id int) (*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.find_order_by_id")
+	defer span.Finish()
+
+	span.SetTag("order.id", id)
+
+	query := "SELECT id, user_id, product_name, amount, status, created_at FROM orders WHERE id = ?"
+
+	var order entities.Order
+
+	// SQL automatically logged by LoggingDB
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.ProductName,
+		&order.Amount,
+		&order.Status,
+		&order.CreatedAt,
+	)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrOrderNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order: %w", err)
+	}
+
+	return &order, nil
+}
+
+// FindAll retrieves all orders
+func (r *OrderRepository) FindAll(ctx context.Context) ([]*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.find_all_orders")
+	defer span.Finish()
+
+	query := "SELECT id, user_id, product_name, amount, status, created_at FROM orders ORDER BY created_at DESC LIMIT 100"
+
+	// SQL automatically logged by LoggingDB
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+// FindByUserID retrieves all orders placed by a user
+func (r *OrderRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.find_orders_by_user_id")
+	defer span.Finish()
+
+	span.SetTag("user.id", userID)
+
+	query := "SELECT id, user_id, product_name, amount, status, created_at FROM orders WHERE user_id = ? ORDER BY created_at DESC"
+
+	// SQL automatically logged by LoggingDB
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orders: %w", err)
+	}
+	defer rows.Close()
+
+	return scanOrders(rows)
+}
+
+// TestSlowQuery runs a slow SELECT for testing slow-query logging and EXPLAIN capture
+// SLEEP guarantees the latency; the LIKE on the unindexed product_name shows
+// up in the plan as a full table scan of orders; term matches literally
+// This method is for testing purposes only
+func (r *OrderRepository) TestSlowQuery(ctx context.Context, delay time.Duration, term string) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.test_slow_query")
+	defer span.Finish()
+
+	query := "SELECT SLEEP(?), (SELECT COUNT(*) FROM orders WHERE product_name LIKE ? ESCAPE '\\\\')"
+
+	var slept, matches int
+	// SQL automatically logged by LoggingDB
+	if err := r.db.QueryRowContext(ctx, query, delay.Seconds(), "%"+escapeLike(term)+"%").Scan(&slept, &matches); err != nil {
+		return 0, fmt.Errorf("failed to run slow query: %w", err)
+	}
+
+	span.SetTag("orders.matches", matches)
+	return matches, nil
+}
+
+// scanOrders reads all order rows from a result set
+func scanOrders(rows *LoggingRows) ([]*entities.Order, error) {
+	var orders []*entities.Order
+	for rows.Next() {
+		var order entities.Order
+		if err := rows.Scan(&order.ID, &order.UserID, &order.ProductName, &order.Amount, &order.Status, &order.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan order: %w", err)
+		}
+		orders = append(orders, &order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+
+	return orders, nil
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+// otherFingerprint collects statements once MaxFingerprints is reached
+var otherFingerprint = fingerprint{ID: "other", Query: "(other statements)"}
+
+// QueryStats keeps in-process rolling statistics per query fingerprint
+// Counts are totals since start; latency percentiles and rows are computed
+// over the last Window executions of each fingerprint
+// It implements port.QueryStatsReader and is safe for concurrent use
+type QueryStats struct {
+	mu              sync.Mutex
+	window          int
+	maxFingerprints int
+	entries         map[string]*queryStatsEntry
+}
+
+// queryStatsEntry is the state of one fingerprint
+type queryStatsEntry struct {
+	fingerprint fingerprint
+	count       int64
+	errors      int64
+	totalTime   time.Duration
+	// ring buffers of the latest executions
+	latencies []time.Duration
+	rows      []int64
+	next      int
+}
+
+// NewQueryStats creates QueryStats keeping window samples per fingerprint
+// for at most maxFingerprints fingerprints; later ones are counted as "other"
+func NewQueryStats(window, maxFingerprints int) *QueryStats {
+	return &QueryStats{
+		window:          window,
+		maxFingerprints: maxFingerprints,
+		entries:         make(map[string]*queryStatsEntry),
+	}
+}
+
+// WithQueryStats records every statement of the LoggingDB in stats
+// Share one QueryStats between repositories to aggregate across them
+func WithQueryStats(stats *QueryStats) Option {
+	return func(db *LoggingDB) {
+		db.log.stats = stats
+	}
+}
+
+// record adds one execution; rows < 0 means the row count is unknown
+func (s *QueryStats) record(fp fingerprint, duration time.Duration, rows int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[fp.ID]
+	if !ok {
+		if len(s.entries) >= s.maxFingerprints {
+			fp = otherFingerprint
+			entry = s.entries[fp.ID]
+		}
+		if entry == nil {
+			entry = &queryStatsEntry{fingerprint: fp}
+			s.entries[fp.ID] = entry
+		}
+	}
+
+	entry.count++
+	entry.totalTime += duration
+	if err != nil {
+		entry.errors++
+	}
+
+	if len(entry.latencies) < s.window {
+		entry.latencies = append(entry.latencies, duration)
+		entry.rows = append(entry.rows, rows)
+		return
+	}
+	entry.latencies[entry.next] = duration
+	entry.rows[entry.next] = rows
+	entry.next = (entry.next + 1) % s.window
+}
+
+// Snapshot returns the statistics of every fingerprint, by total time descending
+func (s *QueryStats) Snapshot() []port.QueryStat {
+	s.mu.Lock()
+	stats := make([]port.QueryStat, 0, len(s.entries))
+	for _, entry := range s.entries {
+		stats = append(stats, entry.stat())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].TotalMs > stats[j].TotalMs
+	})
+	return stats
+}
+
+// stat summarizes an entry; the caller must hold the QueryStats lock
+func (e *queryStatsEntry) stat() port.QueryStat {
+	latencies := append([]time.Duration(nil), e.latencies...)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	var rowsTotal, rowsSamples int64
+	for _, r := range e.rows {
+		if r >= 0 {
+			rowsTotal += r
+			rowsSamples++
+		}
+	}
+	rowsAvg := -1.0
+	if rowsSamples > 0 {
+		rowsAvg = float64(rowsTotal) / float64(rowsSamples)
+	}
+
+	return port.QueryStat{
+		Fingerprint: e.fingerprint.ID,
+		Query:       e.fingerprint.Query,
+		Count:       e.count,
+		Errors:      e.errors,
+		TotalMs:     durationMs(e.totalTime),
+		P50Ms:       durationMs(percentile(latencies, 50)),
+		P95Ms:       durationMs(percentile(latencies, 95)),
+		P99Ms:       durationMs(percentile(latencies, 99)),
+		RowsAvg:     rowsAvg,
+		Samples:     len(latencies),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// durationMs converts a duration to float milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	ten := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      int
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single sample", []time.Duration{7}, 99, 7},
+		{"p50 of ten", ten, 50, 5},
+		{"p95 of ten", ten, 95, 10},
+		{"p99 of ten", ten, 99, 10},
+		{"p0 is the minimum", ten, 0, 1},
+		{"p100 is the maximum", ten, 100, 10},
+		{"p50 of two", []time.Duration{1, 2}, 50, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.p); got != tt.want {
+				t.Fatalf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQueryStatsWindowAndOther(t *testing.T) {
+	stats := NewQueryStats(2, 1)
+	a := fingerprintOf("SELECT id FROM a WHERE id = ?")
+	b := fingerprintOf("SELECT id FROM b WHERE id = ?")
+
+	stats.record(a, 1*time.Millisecond, 1, nil)
+	stats.record(a, 2*time.Millisecond, 3, nil)
+	stats.record(a, 5*time.Millisecond, -1, errors.New("boom"))
+	stats.record(b, 1*time.Millisecond, 1, nil)
+
+	snapshot := stats.Snapshot()
+	if len(snapshot) != 2 {
+		t.Fatalf("want 2 entries, got %v", snapshot)
+	}
+
+	got := snapshot[0]
+	if got.Fingerprint != a.ID || got.Count != 3 || got.Errors != 1 || got.TotalMs != 8 {
+		t.Fatalf("want totals over all executions of %s, got %+v", a.ID, got)
+	}
+	// The window keeps the last two executions: 2ms/3 rows and 5ms/unknown rows
+	if got.Samples != 2 || got.P50Ms != 2 || got.P99Ms != 5 || got.RowsAvg != 3 {
+		t.Fatalf("want window of the last two executions, got %+v", got)
+	}
+
+	if other := snapshot[1]; other.Fingerprint != otherFingerprint.ID || other.Count != 1 {
+		t.Fatalf("want fingerprints past the cap counted as other, got %+v", other)
+	}
+}
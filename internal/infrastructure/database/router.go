@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Values of the db.role span tag, log attribute and metric tag
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Reasons a statement was routed where it was, tagged as db.route on the span
+const (
+	routeReplica        = "replica"
+	routeWrite          = "write"
+	routeReadYourWrites = "read_your_writes"
+	routePrimaryReads   = "primary_required"
+	routeNoReplica      = "replica_unavailable"
+)
+
+// WithRole tags every statement of the LoggingDB with db.role on the current
+// span, in SQL logs and on the sql.query.* metrics
+func WithRole(role string) Option {
+	return func(db *LoggingDB) {
+		db.log = db.log.with("db.role", role)
+		db.log.role = role
+	}
+}
+
+// ReplicaOptions configures replica health checking in Router
+type ReplicaOptions struct {
+	// HealthInterval is how often each replica is pinged
+	HealthInterval time.Duration
+	// HealthTimeout bounds a single ping
+	HealthTimeout time.Duration
+}
+
+// Router sends reads to MySQL read replicas and everything else to the primary
+// A SELECT goes to a healthy replica, picked round robin, unless it locks rows,
+// the request has already written (see appcontext.WithReadYourWrites), the
+// caller requires the primary (see appcontext.WithPrimaryReads), or no
+// replica is healthy; writes always go to the primary and mark the request
+// Transactions are not routed: UnitOfWork runs them on the primary
+// An unhealthy replica stops receiving reads within one health interval
+type Router struct {
+	primary  *LoggingDB
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *slog.Logger
+	metrics  port.Metrics
+}
+
+// replica is one read replica and its last known health
+type replica struct {
+	name    string
+	raw     *sql.DB
+	db      *LoggingDB
+	healthy atomic.Bool
+}
+
+// NewRouter creates a Router over primary and replicas and starts checking
+// replica health every opts.HealthInterval until ctx is done
+// Replicas receive reads only after their first successful check
+func NewRouter(ctx context.Context, primary *sql.DB, replicas []*sql.DB, opts ReplicaOptions, logger *slog.Logger, metrics port.Metrics, dbOpts ...Option) *Router {
+	r := &Router{
+		primary: NewLoggingDB(primary, logger, metrics, withOption(dbOpts, WithRole(RolePrimary))...),
+		logger:  logger,
+		metrics: metrics,
+	}
+	for i, db := range replicas {
+		name := ReplicaName(i)
+		loggingDB := NewLoggingDB(db, logger, metrics, withOption(dbOpts, WithRole(RoleReplica))...)
+		loggingDB.log = loggingDB.log.with("db.replica", name)
+		r.replicas = append(r.replicas, &replica{name: name, raw: db, db: loggingDB})
+	}
+
+	if len(r.replicas) > 0 {
+		go r.monitor(ctx, opts)
+	}
+	return r
+}
+
+// withOption returns a copy of opts followed by opt
+func withOption(opts []Option, opt Option) []Option {
+	return append(append([]Option{}, opts...), opt)
+}
+
+// ReplicaName returns the name of the i-th replica used in logs, metrics and readiness
+func ReplicaName(i int) string {
+	return "mysql-replica-" + strconv.Itoa(i+1)
+}
+
+// monitor pings every replica now and then every opts.HealthInterval
+func (r *Router) monitor(ctx context.Context, opts ReplicaOptions) {
+	ticker := time.NewTicker(opts.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, rep := range r.replicas {
+			r.check(ctx, rep, opts.HealthTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pings a replica and records the result, logging health changes
+func (r *Router) check(ctx context.Context, rep *replica, timeout time.Duration) {
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := rep.raw.PingContext(pingCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	healthy := err == nil
+	if rep.healthy.Swap(healthy) != healthy {
+		if healthy {
+			r.logger.Info("MySQL replica is healthy, routing reads to it", "db.replica", rep.name)
+		} else {
+			r.logger.Warn("MySQL replica is unhealthy, routing its reads to the primary",
+				"db.replica", rep.name, "error", err.Error())
+		}
+	}
+
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	r.metrics.Gauge("sql.replica.healthy", value, "db.replica:"+rep.name)
+}
+
+// ExecContext runs a write on the primary and marks the request as written
+func (r *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tagRoute(ctx, routeWrite)
+	appcontext.MarkWritten(ctx)
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext runs a query on the database chosen by route
+func (r *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*LoggingRows, error) {
+	return r.route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a single-row query on the database chosen by route
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *LoggingRow {
+	return r.route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// route picks the database for a statement returning rows
+func (r *Router) route(ctx context.Context, query string) *LoggingDB {
+	switch {
+	case !isReplicaSafe(query):
+		// Locking reads take row locks on the primary, so treat them as writes
+		tagRoute(ctx, routeWrite)
+		appcontext.MarkWritten(ctx)
+		return r.primary
+	case appcontext.HasWritten(ctx):
+		tagRoute(ctx, routeReadYourWrites)
+		return r.primary
+	case appcontext.ReadsPrimary(ctx):
+		tagRoute(ctx, routePrimaryReads)
+		return r.primary
+	}
+
+	if rep := r.pick(); rep != nil {
+		tagRoute(ctx, routeReplica)
+		return rep.db
+	}
+
+	tagRoute(ctx, routeNoReplica)
+	r.metrics.Incr("sql.replica.fallback", "sql.operation:"+sqlOperation(query))
+	return r.primary
+}
+
+// pick returns the next healthy replica, or nil when none is healthy
+func (r *Router) pick() *replica {
+	n := len(r.replicas)
+	if n == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := 0; i < n; i++ {
+		rep := r.replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// tagRoute records why the current statement went where it did
+func tagRoute(ctx context.Context, reason string) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		span.SetTag("db.route", reason)
+	}
+}
+
+// isReplicaSafe reports whether query is a plain read that a replica may serve
+func isReplicaSafe(query string) bool {
+	if op := sqlOperation(query); op != "select" && op != "with" {
+		return false
+	}
+	lower := strings.ToLower(query)
+	return !strings.Contains(lower, "for update") &&
+		!strings.Contains(lower, "for share") &&
+		!strings.Contains(lower, "lock in share mode")
+}
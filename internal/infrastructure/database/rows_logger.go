@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// LoggingRows wraps sql.Rows so the query is logged once the result set is
+// finished, with the number of rows scanned and the final error
+// The log is written when Next returns false or Close is called, whichever is first
+type LoggingRows struct {
+	*sql.Rows
+	done    func(count int64, err error)
+	once    sync.Once
+	count   int64
+	scanErr error
+}
+
+// Next wraps sql.Rows.Next and logs the query when the rows are exhausted
+func (r *LoggingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.finish()
+	return false
+}
+
+// Scan wraps sql.Rows.Scan, counting scanned rows and keeping the first error
+func (r *LoggingRows) Scan(dest ...interface{}) error {
+	err := r.Rows.Scan(dest...)
+	if err != nil {
+		if r.scanErr == nil {
+			r.scanErr = err
+		}
+		return err
+	}
+	r.count++
+	return nil
+}
+
+// Close wraps sql.Rows.Close and logs the query if it has not been logged yet
+func (r *LoggingRows) Close() error {
+	err := r.Rows.Close()
+	r.finish()
+	return err
+}
+
+// finish logs the query once with the row count and the iteration or scan error
+func (r *LoggingRows) finish() {
+	r.once.Do(func() {
+		err := r.Rows.Err()
+		if err == nil {
+			err = r.scanErr
+		}
+		r.done(r.count, err)
+	})
+}
+
+// LoggingRow wraps sql.Row so the query is logged by Scan, once its error is known
+// sql.ErrNoRows is logged as a successful query that returned 0 rows
+type LoggingRow struct {
+	row  *sql.Row
+	done func(count int64, err error)
+}
+
+// Scan wraps sql.Row.Scan and logs the query
+func (r *LoggingRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	switch {
+	case err == nil:
+		r.done(1, nil)
+	case errors.Is(err, sql.ErrNoRows):
+		r.done(0, nil)
+	default:
+		r.done(0, err)
+	}
+	return err
+}
+
+// Err wraps sql.Row.Err
+func (r *LoggingRow) Err() error {
+	return r.row.Err()
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// maxConcurrentExplains bounds background EXPLAINs per LoggingDB so a burst of
+// slow queries cannot add a burst of extra load on the database
+const maxConcurrentExplains = 2
+
+// SlowQueryOptions configures slow-query detection in LoggingDB
+// Statements taking at least Warn are logged at WARN, at least Error at ERROR;
+// a zero threshold is disabled
+type SlowQueryOptions struct {
+	Warn  time.Duration
+	Error time.Duration
+	// Explain runs EXPLAIN FORMAT=JSON for slow SELECTs in the background;
+	// the plan is added to the log record and to a mysql.explain span
+	Explain        bool
+	ExplainTimeout time.Duration
+}
+
+// Option configures a LoggingDB
+type Option func(*LoggingDB)
+
+// WithSlowQuery enables slow-query levels and, optionally, EXPLAIN capture
+func WithSlowQuery(opts SlowQueryOptions) Option {
+	return func(db *LoggingDB) {
+		db.log.slow = opts
+		if opts.Explain {
+			db.log.explain = &explainer{
+				db:      db.DB,
+				timeout: opts.ExplainTimeout,
+				sem:     make(chan struct{}, maxConcurrentExplains),
+			}
+		}
+	}
+}
+
+// level returns the log level for a statement that took duration, and the
+// exceeded threshold ("warn" or "error"), or "" when the statement is not slow
+func (o SlowQueryOptions) level(duration time.Duration) (slog.Level, string) {
+	switch {
+	case o.Error > 0 && duration >= o.Error:
+		return slog.LevelError, "error"
+	case o.Warn > 0 && duration >= o.Warn:
+		return slog.LevelWarn, "warn"
+	default:
+		return slog.LevelInfo, ""
+	}
+}
+
+// explainer runs EXPLAIN for slow SELECTs outside the request path
+type explainer struct {
+	db      *sql.DB
+	timeout time.Duration
+	sem     chan struct{}
+}
+
+// run explains query in the background and calls done with the JSON plan
+// It reports false, without calling done, when too many EXPLAINs are running
+func (e *explainer) run(ctx context.Context, query string, args []interface{}, done func(plan json.RawMessage, err error)) bool {
+	select {
+	case e.sem <- struct{}{}:
+	default:
+		return false
+	}
+
+	var opts []ddtrace.StartSpanOption
+	if parent, ok := tracer.SpanFromContext(ctx); ok {
+		opts = append(opts, tracer.ChildOf(parent.Context()))
+	}
+
+	go func() {
+		defer func() { <-e.sem }()
+
+		span := tracer.StartSpan("mysql.explain", opts...)
+		// The statement's context may already be canceled when the request ends
+		explainCtx, cancel := context.WithTimeout(tracer.ContextWithSpan(context.Background(), span), e.timeout)
+		defer cancel()
+
+		var plan string
+		err := e.db.QueryRowContext(explainCtx, "EXPLAIN FORMAT=JSON "+query, args...).Scan(&plan)
+		if err == nil {
+			span.SetTag("sql.explain", plan)
+		}
+		span.Finish(tracer.WithError(err))
+
+		if err != nil || !json.Valid([]byte(plan)) {
+			done(nil, err)
+			return
+		}
+		done(json.RawMessage(plan), nil)
+	}()
+	return true
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// UnitOfWork implements port.UnitOfWork with a MySQL transaction
+type UnitOfWork struct {
+	db *LoggingDB
+}
+
+// NewUnitOfWork creates a new UnitOfWork
+func NewUnitOfWork(db *sql.DB, logger *slog.Logger, metrics port.Metrics, opts ...Option) *UnitOfWork {
+	return &UnitOfWork{
+		db: NewLoggingDB(db, logger, metrics, opts...),
+	}
+}
+
+// Do runs fn in a transaction with transaction-bound repositories
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos port.TxRepositories) error) (err error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.transaction")
+	defer func() { span.Finish(tracer.WithError(err)) }()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	span.SetTag("db.tx_id", tx.ID())
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	repos := port.TxRepositories{
+		Users:  &UserRepository{db: tx},
+		Orders: &OrderRepository{db: tx},
+	}
+
+	if err := fn(ctx, repos); err != nil {
+		span.SetTag("db.tx_outcome", "rollback")
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	span.SetTag("db.tx_outcome", "commit")
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Later reads in the request must see the committed rows, so skip replicas
+	appcontext.MarkWritten(ctx)
+	return nil
+}
@@ -3,22 +3,32 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 // UserRepository implements entities.UserRepository for MySQL
 type UserRepository struct {
-	db *LoggingDB
+	db executor
 }
 
 // NewUserRepository creates a new UserRepository
-func NewUserRepository(db *sql.DB, logger *slog.Logger) *UserRepository {
+func NewUserRepository(db *sql.DB, logger *slog.Logger, metrics port.Metrics, opts ...Option) *UserRepository {
 	return &UserRepository{
-		db: NewLoggingDB(db, logger),
+		db: NewLoggingDB(db, logger, metrics, opts...),
+	}
+}
+
+// NewRoutedUserRepository creates a UserRepository that reads from replicas through router
+func NewRoutedUserRepository(router *Router) *UserRepository {
+	return &UserRepository{
+		db: router,
 	}
 }
 
@@ -31,6 +41,9 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 
 	// SQL automatically logged by LoggingDB
 	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.CreatedAt)
+	if isMySQLError(err, mysqlErrDuplicateEntry) {
+		return fmt.Errorf("failed to insert user: %w", entities.ErrDuplicateEmail)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
@@ -44,6 +57,31 @@ func (r *UserRepository) Create(ctx context.Context, user *entities.User) error
 	return nil
 }
 
+// Upsert inserts a user or updates the existing user with the same email
+// LAST_INSERT_ID(id) makes LastInsertId return the existing row's ID on update
+func (r *UserRepository) Upsert(ctx context.Context, user *entities.User) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.upsert_user")
+	defer span.Finish()
+
+	query := "INSERT INTO users (name, email, created_at) VALUES (?, ?, ?) AS new " +
+		"ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(users.id), name = new.name, created_at = new.created_at"
+
+	// SQL automatically logged by LoggingDB
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	user.ID = int(id)
+	span.SetTag("user.id", user.ID)
+	return nil
+}
+
 // FindByID finds a user by ID
 func (r *UserRepository) FindByID(ctx context.Context, id int) (*entities.User, error) {
 	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.find_user_by_id")
@@ -61,8 +99,8 @@ func (r *UserRepository) FindByID(ctx context.Context, id int) (*entities.User,
 		&user.CreatedAt,
 	)
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.ErrUserNotFound
 	}
 
 	if err != nil {
@@ -72,15 +110,42 @@ func (r *UserRepository) FindByID(ctx context.Context, id int) (*entities.User,
 	return &user, nil
 }
 
-// FindAll retrieves all users
-func (r *UserRepository) FindAll(ctx context.Context) ([]*entities.User, error) {
+// FindAll retrieves users page by page using keyset pagination
+func (r *UserRepository) FindAll(ctx context.Context, q port.UserListQuery) ([]*entities.User, error) {
 	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.find_all_users")
 	defer span.Finish()
 
-	query := "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC LIMIT 100"
+	var conditions []string
+	var args []interface{}
+
+	if q.AfterCreated != nil {
+		conditions = append(conditions, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, *q.AfterCreated, *q.AfterCreated, q.AfterID)
+	}
+	if q.EmailPrefix != "" {
+		conditions = append(conditions, "email LIKE ?")
+		args = append(args, escapeLike(q.EmailPrefix)+"%")
+	}
+	if q.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *q.CreatedAfter)
+	}
+	if q.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *q.CreatedBefore)
+	}
+
+	query := "SELECT id, name, email, created_at FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, q.Limit)
+
+	span.SetTag("query.conditions", len(conditions))
 
 	// SQL automatically logged by LoggingDB
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -90,14 +155,87 @@ func (r *UserRepository) FindAll(ctx context.Context) ([]*entities.User, error)
 	for rows.Next() {
 		var user entities.User
 		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt); err != nil {
-			continue
+			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, &user)
 	}
+	// A page cut short by an iteration error would produce a wrong cursor
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
 
 	return users, nil
 }
 
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Update updates an existing user's name and email
+func (r *UserRepository) Update(ctx context.Context, user *entities.User) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.update_user")
+	defer span.Finish()
+
+	span.SetTag("user.id", user.ID)
+
+	query := "UPDATE users SET name = ?, email = ? WHERE id = ?"
+
+	// SQL automatically logged by LoggingDB
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.ID)
+	if isMySQLError(err, mysqlErrDuplicateEntry) {
+		return fmt.Errorf("failed to update user: %w", entities.ErrDuplicateEmail)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	// MySQL reports 0 rows affected when the values are unchanged,
+	// so confirm the row actually exists before reporting not found
+	if rowsAffected == 0 {
+		if _, err := r.FindByID(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete deletes a user by ID
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "mysql.delete_user")
+	defer span.Finish()
+
+	span.SetTag("user.id", id)
+
+	query := "DELETE FROM users WHERE id = ?"
+
+	// SQL automatically logged by LoggingDB
+	result, err := r.db.ExecContext(ctx, query, id)
+	if isMySQLError(err, mysqlErrRowIsReferenced) {
+		return fmt.Errorf("failed to delete user: %w", entities.ErrUserHasOrders)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return entities.ErrUserNotFound
+	}
+
+	return nil
+}
+
 // TestPanic deliberately triggers a panic to test recovery middleware
 // This method is for testing purposes only
 func (r *UserRepository) TestPanic(ctx context.Context) error {
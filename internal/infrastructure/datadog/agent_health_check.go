@@ -0,0 +1,59 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// AgentHealthCheck implements port.HealthChecker by calling the trace agent's /info endpoint
+// It is non-critical: the API keeps serving traffic without the agent,
+// but the readiness body shows that telemetry is being dropped
+type AgentHealthCheck struct {
+	url     string
+	client  *http.Client
+	timeout time.Duration
+}
+
+// NewAgentHealthCheck creates a new agent health check for host:port
+func NewAgentHealthCheck(host string, port int, timeout time.Duration) *AgentHealthCheck {
+	return &AgentHealthCheck{
+		url:     fmt.Sprintf("http://%s:%d/info", host, port),
+		client:  &http.Client{},
+		timeout: timeout,
+	}
+}
+
+// Name returns the component name reported by the readiness probe
+func (h *AgentHealthCheck) Name() string {
+	return "datadog_agent"
+}
+
+// Critical reports that the agent is optional for serving traffic
+func (h *AgentHealthCheck) Critical() bool {
+	return false
+}
+
+// Check requests the agent's /info endpoint and expects 200 OK
+func (h *AgentHealthCheck) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build agent request: %w", err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("agent unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("agent returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
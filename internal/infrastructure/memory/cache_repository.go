@@ -0,0 +1,287 @@
+package memory
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+// cacheEntry is a stored value and its absolute expiry (zero means no expiry)
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+// expired reports whether the entry is past its expiry at now
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// CacheRepository implements port.CacheRepository in process
+// Values are stored as strings like Redis does; expired entries are dropped
+// lazily when they are next touched, so there is no background janitor
+type CacheRepository struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	// tags maps a tag to its keys and their expiry, like the Redis tag sets
+	tags map[string]map[string]time.Time
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewCacheRepository creates a new CacheRepository with the given default entry TTL
+func NewCacheRepository(ttl time.Duration) *CacheRepository {
+	return &CacheRepository{
+		entries: make(map[string]cacheEntry),
+		tags:    make(map[string]map[string]time.Time),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// GetTTL returns the configured default TTL
+func (r *CacheRepository) GetTTL() time.Duration {
+	return r.ttl
+}
+
+// resolveTTL returns the per-call TTL, falling back to the default
+func (r *CacheRepository) resolveTTL(o port.CacheOptions) time.Duration {
+	if o.TTL > 0 {
+		return o.TTL
+	}
+	return r.ttl
+}
+
+// expiry converts a TTL into an absolute expiry; ttl <= 0 never expires
+func (r *CacheRepository) expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return r.now().Add(ttl)
+}
+
+// lookup returns the live entry for key, dropping it if it has expired
+// The caller must hold mu
+func (r *CacheRepository) lookup(key string) (cacheEntry, bool) {
+	entry, ok := r.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	if entry.expired(r.now()) {
+		delete(r.entries, key)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// write stores value under key and records its tags
+// Tag members whose entries have expired are trimmed as the tag is written
+// The caller must hold mu
+func (r *CacheRepository) write(key string, value interface{}, o port.CacheOptions) error {
+	s, err := formatValue(value)
+	if err != nil {
+		return err
+	}
+	entry := cacheEntry{value: s, expiresAt: r.expiry(r.resolveTTL(o))}
+	r.entries[key] = entry
+
+	now := r.now()
+	for _, tag := range o.Tags {
+		members := r.tags[tag]
+		if members == nil {
+			members = make(map[string]time.Time)
+			r.tags[tag] = members
+		}
+		for member, expiresAt := range members {
+			if (cacheEntry{expiresAt: expiresAt}).expired(now) {
+				delete(members, member)
+			}
+		}
+		members[key] = entry.expiresAt
+	}
+	return nil
+}
+
+// formatValue converts a value to its stored string form the way go-redis encodes arguments
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", fmt.Errorf("failed to set cache: %w", err)
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// Set stores a value in cache
+func (r *CacheRepository) Set(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.write(key, value, port.ApplyCacheOptions(opts...))
+}
+
+// SetNX stores a value only if the key does not exist and reports whether it was stored
+func (r *CacheRepository) SetNX(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lookup(key); ok {
+		return false, nil
+	}
+	if err := r.write(key, value, port.ApplyCacheOptions(opts...)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get retrieves a value from cache
+func (r *CacheRepository) Get(ctx context.Context, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.lookup(key)
+	if !ok {
+		return "", port.ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+// MGet retrieves several values at once
+func (r *CacheRepository) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if entry, ok := r.lookup(key); ok {
+			values[key] = entry.value
+		}
+	}
+	return values, nil
+}
+
+// MSet stores several values at once
+func (r *CacheRepository) MSet(ctx context.Context, values map[string]interface{}, opts ...port.CacheOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	o := port.ApplyCacheOptions(opts...)
+	for key, value := range values {
+		if err := r.write(key, value, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes a value from cache
+func (r *CacheRepository) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, key)
+	return nil
+}
+
+// Expire updates the TTL of an existing key; ttl <= 0 deletes it like Redis does
+func (r *CacheRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.lookup(key)
+	if !ok {
+		return false, nil
+	}
+	if ttl <= 0 {
+		delete(r.entries, key)
+		return true, nil
+	}
+	entry.expiresAt = r.expiry(ttl)
+	r.entries[key] = entry
+	return true, nil
+}
+
+// TTL returns the remaining TTL of a key
+func (r *CacheRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.lookup(key)
+	if !ok {
+		return 0, port.ErrCacheMiss
+	}
+	if entry.expiresAt.IsZero() {
+		return port.NoExpiry, nil
+	}
+	return entry.expiresAt.Sub(r.now()), nil
+}
+
+// DeletePattern deletes keys matching a glob pattern
+// Patterns use path.Match syntax, which agrees with Redis globs for keys without '/'
+func (r *CacheRepository) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key := range r.entries {
+		ok, err := path.Match(pattern, key)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to scan cache: %w", err)
+		}
+		if !ok {
+			continue
+		}
+		if _, live := r.lookup(key); live {
+			delete(r.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// InvalidateTag deletes every key written with the tag, and the tag itself
+func (r *CacheRepository) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for key := range r.tags[tag] {
+		if _, live := r.lookup(key); live {
+			delete(r.entries, key)
+			deleted++
+		}
+	}
+	delete(r.tags, tag)
+	return deleted, nil
+}
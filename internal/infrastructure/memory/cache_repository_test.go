@@ -0,0 +1,40 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+func TestCacheRepositoryTagsTrimExpiredKeys(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := NewCacheRepository(time.Minute)
+	repo.now = func() time.Time { return now }
+
+	if err := repo.Set(ctx, "user:1", "a", port.WithTags("users")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Set(ctx, "user:2", "b", port.WithTags("users"), port.WithTTL(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// user:1 expires; the next write to the tag trims it
+	now = now.Add(2 * time.Minute)
+	if err := repo.Set(ctx, "user:3", "c", port.WithTags("users")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := repo.tags["users"]["user:1"]; ok || len(repo.tags["users"]) != 2 {
+		t.Fatalf("want the expired key trimmed from the tag, got %v", repo.tags["users"])
+	}
+
+	n, err := repo.InvalidateTag(ctx, "users")
+	if err != nil || n != 2 {
+		t.Fatalf("want 2 keys invalidated, got %d, %v", n, err)
+	}
+	if n, err := repo.InvalidateTag(ctx, "users"); err != nil || n != 0 {
+		t.Fatalf("want 0 for an invalidated tag, got %d, %v", n, err)
+	}
+}
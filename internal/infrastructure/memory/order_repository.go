@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// findAllOrdersLimit matches the LIMIT of the MySQL FindAll query
+const findAllOrdersLimit = 100
+
+// OrderRepository implements port.OrderRepository in process
+// Creating an order for an unknown user fails like the orders.user_id foreign key
+type OrderRepository struct {
+	store *Store
+	undo  *undoLog
+}
+
+// NewOrderRepository creates a new OrderRepository backed by store
+func NewOrderRepository(store *Store) *OrderRepository {
+	return &OrderRepository{
+		store: store,
+	}
+}
+
+// Create creates a new order
+func (r *OrderRepository) Create(ctx context.Context, order *entities.Order) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.create_order")
+	defer span.Finish()
+
+	span.SetTag("user.id", order.UserID)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[order.UserID]; !ok {
+		return fmt.Errorf("failed to insert order: %w", entities.ErrUserNotFound)
+	}
+
+	order.ID = r.store.nextOrderID
+	r.store.nextOrderID++
+
+	stored := *order
+	stored.CreatedAt = order.CreatedAt.Truncate(time.Second)
+	r.store.putOrder(r.undo, stored)
+	return nil
+}
+
+// InsertIfAbsent inserts an order with its explicit ID and leaves an existing
+// order with that ID untouched
+// Like AUTO_INCREMENT, later Creates are numbered after the highest ID seen
+func (r *OrderRepository) InsertIfAbsent(ctx context.Context, order *entities.Order) (bool, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.insert_order_if_absent")
+	defer span.Finish()
+
+	span.SetTag("order.id", order.ID)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[order.UserID]; !ok {
+		return false, fmt.Errorf("failed to insert order: %w", entities.ErrUserNotFound)
+	}
+	if _, ok := r.store.orders[order.ID]; ok {
+		return false, nil
+	}
+
+	stored := *order
+	stored.CreatedAt = order.CreatedAt.Truncate(time.Second)
+	r.store.putOrder(r.undo, stored)
+	if order.ID >= r.store.nextOrderID {
+		r.store.nextOrderID = order.ID + 1
+	}
+	return true, nil
+}
+
+// FindByID finds an order by ID
+func (r *OrderRepository) FindByID(ctx context.Context, id int) (*entities.Order, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.find_order_by_id")
+	defer span.Finish()
+
+	span.SetTag("order.id", id)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	order, ok := r.store.orders[id]
+	if !ok {
+		return nil, entities.ErrOrderNotFound
+	}
+	return &order, nil
+}
+
+// FindAll retrieves the most recent orders
+func (r *OrderRepository) FindAll(ctx context.Context) ([]*entities.Order, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.find_all_orders")
+	defer span.Finish()
+
+	orders := r.filter(func(*entities.Order) bool { return true })
+	if len(orders) > findAllOrdersLimit {
+		orders = orders[:findAllOrdersLimit]
+	}
+	return orders, nil
+}
+
+// FindByUserID retrieves all orders placed by a user
+func (r *OrderRepository) FindByUserID(ctx context.Context, userID int) ([]*entities.Order, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.find_orders_by_user_id")
+	defer span.Finish()
+
+	span.SetTag("user.id", userID)
+
+	return r.filter(func(o *entities.Order) bool { return o.UserID == userID }), nil
+}
+
+// TestSlowQuery waits for delay, then counts orders whose product name contains term
+// This method is for testing purposes only
+func (r *OrderRepository) TestSlowQuery(ctx context.Context, delay time.Duration, term string) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "memory.test_slow_query")
+	defer span.Finish()
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+
+	term = strings.ToLower(term)
+	matches := len(r.filter(func(o *entities.Order) bool {
+		return strings.Contains(strings.ToLower(o.ProductName), term)
+	}))
+
+	span.SetTag("orders.matches", matches)
+	return matches, nil
+}
+
+// filter returns copies of the matching orders, newest first
+func (r *OrderRepository) filter(match func(*entities.Order) bool) []*entities.Order {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var orders []*entities.Order
+	for _, order := range r.store.orders {
+		order := order
+		if match(&order) {
+			orders = append(orders, &order)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].CreatedAt.After(orders[j].CreatedAt)
+		}
+		return orders[i].ID > orders[j].ID
+	})
+	return orders
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+)
+
+// Store holds the in-process tables shared by the memory repositories
+// It plays the role of *sql.DB for the MySQL repositories, so constraints
+// that span tables (the orders.user_id foreign key) can be enforced
+type Store struct {
+	mu sync.RWMutex
+	// txMu serializes units of work so they never interleave writes to one row
+	txMu        sync.Mutex
+	users       map[int]entities.User
+	orders      map[int]entities.Order
+	nextUserID  int
+	nextOrderID int
+}
+
+// NewStore creates an empty Store; IDs start at 1 like AUTO_INCREMENT
+func NewStore() *Store {
+	return &Store{
+		users:       make(map[int]entities.User),
+		orders:      make(map[int]entities.Order),
+		nextUserID:  1,
+		nextOrderID: 1,
+	}
+}
+
+// userHasOrders reports whether any order references the user
+// The caller must hold mu
+func (s *Store) userHasOrders(userID int) bool {
+	for _, order := range s.orders {
+		if order.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// undoLog records how to reverse the writes of one unit of work
+// A nil *undoLog records nothing, for repositories used outside a unit of work
+type undoLog struct {
+	steps []func()
+}
+
+// add records a step that reverses one write
+func (l *undoLog) add(step func()) {
+	if l != nil {
+		l.steps = append(l.steps, step)
+	}
+}
+
+// rollback reverses the recorded writes, newest first
+// Rows the unit of work did not touch are left alone, so concurrent writes
+// made outside it survive; like AUTO_INCREMENT, used IDs are not handed out again
+func (s *Store) rollback(l *undoLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(l.steps) - 1; i >= 0; i-- {
+		l.steps[i]()
+	}
+}
+
+// putUser stores a user, recording its previous version in undo
+// The caller must hold mu
+func (s *Store) putUser(undo *undoLog, user entities.User) {
+	prev, existed := s.users[user.ID]
+	undo.add(func() {
+		if existed {
+			s.users[user.ID] = prev
+		} else {
+			delete(s.users, user.ID)
+		}
+	})
+	s.users[user.ID] = user
+}
+
+// deleteUser removes a user, recording it in undo
+// The caller must hold mu
+func (s *Store) deleteUser(undo *undoLog, id int) {
+	prev := s.users[id]
+	undo.add(func() { s.users[id] = prev })
+	delete(s.users, id)
+}
+
+// putOrder stores an order, recording its previous version in undo
+// The caller must hold mu
+func (s *Store) putOrder(undo *undoLog, order entities.Order) {
+	prev, existed := s.orders[order.ID]
+	undo.add(func() {
+		if existed {
+			s.orders[order.ID] = prev
+		} else {
+			delete(s.orders, order.ID)
+		}
+	})
+	s.orders[order.ID] = order
+}
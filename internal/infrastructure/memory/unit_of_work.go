@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// UnitOfWork implements port.UnitOfWork in process
+// Units of work run one at a time and record an undo log of their own writes;
+// rollback replays it, so writes made outside the unit of work are kept
+type UnitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork creates a new UnitOfWork backed by store
+func NewUnitOfWork(store *Store) *UnitOfWork {
+	return &UnitOfWork{
+		store: store,
+	}
+}
+
+// Do runs fn against the store and undoes fn's writes if it fails
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, repos port.TxRepositories) error) (err error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "memory.transaction")
+	defer func() { span.Finish(tracer.WithError(err)) }()
+
+	u.store.txMu.Lock()
+	defer u.store.txMu.Unlock()
+
+	undo := &undoLog{}
+
+	defer func() {
+		if p := recover(); p != nil {
+			u.store.rollback(undo)
+			panic(p)
+		}
+	}()
+
+	repos := port.TxRepositories{
+		Users:  &UserRepository{store: u.store, undo: undo},
+		Orders: &OrderRepository{store: u.store, undo: undo},
+	}
+
+	if err := fn(ctx, repos); err != nil {
+		span.SetTag("db.tx_outcome", "rollback")
+		u.store.rollback(undo)
+		return err
+	}
+
+	span.SetTag("db.tx_outcome", "commit")
+	return nil
+}
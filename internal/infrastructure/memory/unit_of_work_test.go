@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+func TestUnitOfWorkRollbackKeepsOutsideWrites(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	users := NewUserRepository(store)
+
+	existing := &entities.User{Name: "Alice", Email: "alice@example.com", CreatedAt: time.Now()}
+	if err := users.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	var outside *entities.User
+	errFail := errors.New("fail")
+	err := NewUnitOfWork(store).Do(ctx, func(ctx context.Context, repos port.TxRepositories) error {
+		if err := repos.Users.Create(ctx, &entities.User{Name: "Bob", Email: "bob@example.com", CreatedAt: time.Now()}); err != nil {
+			return err
+		}
+		renamed := *existing
+		renamed.Name = "Alicia"
+		if err := repos.Users.Update(ctx, &renamed); err != nil {
+			return err
+		}
+
+		// A write made outside the unit of work while it is running
+		outside = &entities.User{Name: "Carol", Email: "carol@example.com", CreatedAt: time.Now()}
+		if err := users.Create(ctx, outside); err != nil {
+			return err
+		}
+		return errFail
+	})
+	if !errors.Is(err, errFail) {
+		t.Fatalf("want fn's error, got %v", err)
+	}
+
+	if got, err := users.FindByID(ctx, existing.ID); err != nil || got.Name != "Alice" {
+		t.Fatalf("want the update undone, got %+v, %v", got, err)
+	}
+	if _, err := users.FindByID(ctx, outside.ID); err != nil {
+		t.Fatalf("want the outside write kept, got %v", err)
+	}
+	all, err := users.FindAll(ctx, port.UserListQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("want Alice and Carol only, got %+v", all)
+	}
+}
@@ -0,0 +1,201 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// UserRepository implements port.UserRepository in process
+// It mirrors the MySQL schema: auto-increment IDs, a case-insensitive unique
+// email (utf8mb4_unicode_ci) and second-precision created_at (TIMESTAMP)
+type UserRepository struct {
+	store *Store
+	undo  *undoLog
+}
+
+// NewUserRepository creates a new UserRepository backed by store
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{
+		store: store,
+	}
+}
+
+// emailTaken reports whether another user already has the email
+// The caller must hold the store lock
+func (r *UserRepository) emailTaken(email string, exceptID int) bool {
+	for id, user := range r.store.users {
+		if id != exceptID && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// Create creates a new user
+func (r *UserRepository) Create(ctx context.Context, user *entities.User) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.create_user")
+	defer span.Finish()
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if r.emailTaken(user.Email, 0) {
+		return fmt.Errorf("failed to insert user: %w", entities.ErrDuplicateEmail)
+	}
+
+	user.ID = r.store.nextUserID
+	r.store.nextUserID++
+
+	stored := *user
+	stored.CreatedAt = user.CreatedAt.Truncate(time.Second)
+	r.store.putUser(r.undo, stored)
+	return nil
+}
+
+// Upsert inserts a user or updates the existing user with the same email
+func (r *UserRepository) Upsert(ctx context.Context, user *entities.User) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.upsert_user")
+	defer span.Finish()
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, stored := range r.store.users {
+		if strings.EqualFold(stored.Email, user.Email) {
+			stored.Name = user.Name
+			stored.CreatedAt = user.CreatedAt.Truncate(time.Second)
+			r.store.putUser(r.undo, stored)
+			user.ID = id
+			return nil
+		}
+	}
+
+	user.ID = r.store.nextUserID
+	r.store.nextUserID++
+
+	stored := *user
+	stored.CreatedAt = user.CreatedAt.Truncate(time.Second)
+	r.store.putUser(r.undo, stored)
+	return nil
+}
+
+// FindByID finds a user by ID
+func (r *UserRepository) FindByID(ctx context.Context, id int) (*entities.User, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.find_user_by_id")
+	defer span.Finish()
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, entities.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// FindAll retrieves users page by page using keyset pagination
+// Results are ordered by created_at DESC, id DESC like the MySQL query
+func (r *UserRepository) FindAll(ctx context.Context, q port.UserListQuery) ([]*entities.User, error) {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.find_all_users")
+	defer span.Finish()
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	prefix := strings.ToLower(q.EmailPrefix)
+
+	users := make([]*entities.User, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		if q.AfterCreated != nil && !(user.CreatedAt.Before(*q.AfterCreated) ||
+			(user.CreatedAt.Equal(*q.AfterCreated) && user.ID < q.AfterID)) {
+			continue
+		}
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(user.Email), prefix) {
+			continue
+		}
+		if q.CreatedAfter != nil && user.CreatedAt.Before(*q.CreatedAfter) {
+			continue
+		}
+		if q.CreatedBefore != nil && !user.CreatedAt.Before(*q.CreatedBefore) {
+			continue
+		}
+		user := user
+		users = append(users, &user)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+
+	if q.Limit > 0 && len(users) > q.Limit {
+		users = users[:q.Limit]
+	}
+
+	span.SetTag("users.count", len(users))
+	return users, nil
+}
+
+// Update updates an existing user's name and email
+func (r *UserRepository) Update(ctx context.Context, user *entities.User) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.update_user")
+	defer span.Finish()
+
+	span.SetTag("user.id", user.ID)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.users[user.ID]
+	if !ok {
+		return entities.ErrUserNotFound
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return fmt.Errorf("failed to update user: %w", entities.ErrDuplicateEmail)
+	}
+
+	stored.Name = user.Name
+	stored.Email = user.Email
+	r.store.putUser(r.undo, stored)
+	return nil
+}
+
+// Delete deletes a user by ID
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.delete_user")
+	defer span.Finish()
+
+	span.SetTag("user.id", id)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[id]; !ok {
+		return entities.ErrUserNotFound
+	}
+	if r.store.userHasOrders(id) {
+		return fmt.Errorf("failed to delete user: %w", entities.ErrUserHasOrders)
+	}
+
+	r.store.deleteUser(r.undo, id)
+	return nil
+}
+
+// TestPanic deliberately triggers a panic to test recovery middleware
+// This method is for testing purposes only
+func (r *UserRepository) TestPanic(ctx context.Context) error {
+	span, _ := tracer.StartSpanFromContext(ctx, "memory.test_panic")
+	defer span.Finish()
+
+	panic("Deliberate panic in repository layer for testing recovery middleware")
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+// NoopMetrics implements port.Metrics by discarding everything
+// Use in tests or when no DogStatsD agent is available
+type NoopMetrics struct{}
+
+// NewNoopMetrics creates a new no-op metrics adapter
+func NewNoopMetrics() port.Metrics {
+	return NoopMetrics{}
+}
+
+// Incr does nothing
+func (NoopMetrics) Incr(name string, tags ...string) {}
+
+// Count does nothing
+func (NoopMetrics) Count(name string, value int64, tags ...string) {}
+
+// Gauge does nothing
+func (NoopMetrics) Gauge(name string, value float64, tags ...string) {}
+
+// Histogram does nothing
+func (NoopMetrics) Histogram(name string, value float64, tags ...string) {}
+
+// Timing does nothing
+func (NoopMetrics) Timing(name string, value time.Duration, tags ...string) {}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+// StatsdMetrics implements port.Metrics with a DogStatsD client
+type StatsdMetrics struct {
+	client statsd.ClientInterface
+}
+
+// NewStatsdMetrics creates a new DogStatsD metrics adapter
+// Global tags (env, service) and the namespace are configured on the client
+func NewStatsdMetrics(client statsd.ClientInterface) port.Metrics {
+	return &StatsdMetrics{
+		client: client,
+	}
+}
+
+// Incr increments a counter by one
+func (m *StatsdMetrics) Incr(name string, tags ...string) {
+	// Metrics are best-effort: UDP/UDS send errors are intentionally ignored
+	_ = m.client.Incr(name, tags, 1)
+}
+
+// Count adds value to a counter
+func (m *StatsdMetrics) Count(name string, value int64, tags ...string) {
+	_ = m.client.Count(name, value, tags, 1)
+}
+
+// Gauge records the current value of a metric
+func (m *StatsdMetrics) Gauge(name string, value float64, tags ...string) {
+	_ = m.client.Gauge(name, value, tags, 1)
+}
+
+// Histogram records a value in a histogram
+func (m *StatsdMetrics) Histogram(name string, value float64, tags ...string) {
+	_ = m.client.Histogram(name, value, tags, 1)
+}
+
+// Timing records a duration in a histogram (milliseconds)
+func (m *StatsdMetrics) Timing(name string, value time.Duration, tags ...string) {
+	_ = m.client.Timing(name, value, tags, 1)
+}
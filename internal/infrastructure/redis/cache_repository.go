@@ -2,57 +2,257 @@ package redis
 
 import (
 	"context"
-	"fmt"
+	"math"
+	"strconv"
 	"time"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
 	"github.com/redis/go-redis/v9"
 )
 
+// scanCount is the SCAN batch size used by DeletePattern
+const scanCount = 100
+
 // CacheRepository implements cache operations for Redis (without tracing)
 type CacheRepository struct {
 	client redis.UniversalClient
 	ttl    time.Duration
 }
 
-// NewCacheRepository creates a new CacheRepository
-func NewCacheRepository(client redis.UniversalClient) *CacheRepository {
+// NewCacheRepository creates a new CacheRepository with the given default entry TTL
+func NewCacheRepository(client redis.UniversalClient, ttl time.Duration) *CacheRepository {
 	return &CacheRepository{
 		client: client,
-		ttl:    5 * time.Minute,
+		ttl:    ttl,
 	}
 }
 
-// GetTTL returns the configured TTL
+// GetTTL returns the configured default TTL
 func (r *CacheRepository) GetTTL() time.Duration {
 	return r.ttl
 }
 
+// resolveTTL returns the per-call TTL, falling back to the default
+func (r *CacheRepository) resolveTTL(o port.CacheOptions) time.Duration {
+	if o.TTL > 0 {
+		return o.TTL
+	}
+	return r.ttl
+}
+
+// tagKey returns the Redis sorted set holding the keys written with a tag
+// Members are scored by their expiry in Unix milliseconds
+func tagKey(tag string) string {
+	return "tagset:" + tag
+}
+
+// addTags records keys in each tag set within pipe
+// Members whose entries have expired are trimmed on every write, so a tag that
+// is written often but never invalidated stays as small as its live keys; the
+// set itself expires with its longest-lived member
+func addTags(ctx context.Context, pipe redis.Pipeliner, keys []string, tags []string, ttl time.Duration) {
+	now := time.Now()
+	expiresAt := math.Inf(1)
+	if ttl > 0 {
+		expiresAt = float64(now.Add(ttl).UnixMilli())
+	}
+
+	for _, tag := range tags {
+		members := make([]redis.Z, len(keys))
+		for i, key := range keys {
+			members[i] = redis.Z{Score: expiresAt, Member: key}
+		}
+		pipe.ZRemRangeByScore(ctx, tagKey(tag), "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+		pipe.ZAddGT(ctx, tagKey(tag), members...)
+		if ttl > 0 {
+			pipe.ExpireNX(ctx, tagKey(tag), ttl)
+			pipe.ExpireGT(ctx, tagKey(tag), ttl)
+		} else {
+			pipe.Persist(ctx, tagKey(tag))
+		}
+	}
+}
+
 // Set stores a value in cache
-func (r *CacheRepository) Set(ctx context.Context, key string, value interface{}) error {
-	if err := r.client.Set(ctx, key, value, r.ttl).Err(); err != nil {
-		return fmt.Errorf("failed to set cache: %w", err)
+func (r *CacheRepository) Set(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) error {
+	o := port.ApplyCacheOptions(opts...)
+	ttl := r.resolveTTL(o)
+
+	if len(o.Tags) == 0 {
+		if err := r.client.Set(ctx, key, value, ttl).Err(); err != nil {
+			return wrapError("failed to set cache", err)
+		}
+		return nil
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, key, value, ttl)
+		addTags(ctx, pipe, []string{key}, o.Tags, ttl)
+		return nil
+	})
+	if err != nil {
+		return wrapError("failed to set cache", err)
 	}
 	return nil
 }
 
+// SetNX stores a value only if the key does not exist and reports whether it was stored
+func (r *CacheRepository) SetNX(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) (bool, error) {
+	o := port.ApplyCacheOptions(opts...)
+	ttl := r.resolveTTL(o)
+
+	ok, err := r.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		return false, wrapError("failed to set cache", err)
+	}
+
+	if ok && len(o.Tags) > 0 {
+		_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			addTags(ctx, pipe, []string{key}, o.Tags, ttl)
+			return nil
+		})
+		if err != nil {
+			return true, wrapError("failed to tag cache key", err)
+		}
+	}
+	return ok, nil
+}
+
 // Get retrieves a value from cache
 func (r *CacheRepository) Get(ctx context.Context, key string) (string, error) {
 	value, err := r.client.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return "", fmt.Errorf("key not found")
+	if err != nil {
+		return "", wrapError("failed to get cache", err)
 	}
 
+	return value, nil
+}
+
+// MGet retrieves several values in one round trip
+func (r *CacheRepository) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	results, err := r.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		return "", fmt.Errorf("failed to get cache: %w", err)
+		return nil, wrapError("failed to get cache", err)
 	}
 
-	return value, nil
+	for i, result := range results {
+		if s, ok := result.(string); ok {
+			values[keys[i]] = s
+		}
+	}
+	return values, nil
+}
+
+// MSet stores several values in one round trip
+// MSET has no TTL, so each key is written with SET ... EX in a transaction
+func (r *CacheRepository) MSet(ctx context.Context, values map[string]interface{}, opts ...port.CacheOption) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	o := port.ApplyCacheOptions(opts...)
+	ttl := r.resolveTTL(o)
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		keys := make([]string, 0, len(values))
+		for key, value := range values {
+			pipe.Set(ctx, key, value, ttl)
+			keys = append(keys, key)
+		}
+		addTags(ctx, pipe, keys, o.Tags, ttl)
+		return nil
+	})
+	if err != nil {
+		return wrapError("failed to set cache", err)
+	}
+	return nil
 }
 
 // Delete removes a value from cache
 func (r *CacheRepository) Delete(ctx context.Context, key string) error {
 	if err := r.client.Del(ctx, key).Err(); err != nil {
-		return fmt.Errorf("failed to delete cache: %w", err)
+		return wrapError("failed to delete cache", err)
 	}
 	return nil
 }
+
+// Expire updates the TTL of an existing key
+func (r *CacheRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.Expire(ctx, key, ttl).Result()
+	if err != nil {
+		return false, wrapError("failed to expire cache", err)
+	}
+	return ok, nil
+}
+
+// TTL returns the remaining TTL of a key
+func (r *CacheRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := r.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, wrapError("failed to get cache ttl", err)
+	}
+
+	// go-redis returns the raw -2 (missing key) and -1 (no expiry) replies
+	switch ttl {
+	case -2:
+		return 0, port.ErrCacheMiss
+	case -1:
+		return port.NoExpiry, nil
+	}
+	return ttl, nil
+}
+
+// DeletePattern deletes keys matching pattern using SCAN, so Redis is never blocked by KEYS
+func (r *CacheRepository) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanCount).Result()
+		if err != nil {
+			return deleted, wrapError("failed to scan cache", err)
+		}
+
+		if len(keys) > 0 {
+			n, err := r.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, wrapError("failed to delete cache", err)
+			}
+			deleted += n
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// InvalidateTag deletes every key written with the tag, and the tag set itself
+func (r *CacheRepository) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	keys, err := r.client.ZRange(ctx, tagKey(tag), 0, -1).Result()
+	if err != nil {
+		return 0, wrapError("failed to read cache tag", err)
+	}
+
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// The tag set itself is not a cache entry, so it is not counted
+	var deleted *redis.IntCmd
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.Unlink(ctx, keys...)
+		pipe.Unlink(ctx, tagKey(tag))
+		return nil
+	})
+	if err != nil {
+		return 0, wrapError("failed to delete cache", err)
+	}
+	return deleted.Val(), nil
+}
@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"github.com/redis/go-redis/v9"
+)
+
+// poolTimeoutMessage is go-redis' connection pool timeout, which is not exported
+const poolTimeoutMessage = "redis: connection pool timeout"
+
+// wrapError adds context to a Redis error and classifies it with the port sentinels
+// so callers can tell a miss, a timeout and a connection failure apart
+func wrapError(action string, err error) error {
+	switch {
+	case errors.Is(err, redis.Nil):
+		return port.ErrCacheMiss
+	case isTimeout(err):
+		return fmt.Errorf("%s: %w: %w", action, port.ErrCacheTimeout, err)
+	case isConnectionError(err):
+		return fmt.Errorf("%s: %w: %w", action, port.ErrCacheUnavailable, err)
+	default:
+		return fmt.Errorf("%s: %w", action, err)
+	}
+}
+
+// isTimeout reports deadline, socket and connection pool timeouts
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || err.Error() == poolTimeoutMessage {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnectionError reports refused, reset or closed connections
+func isConnectionError(err error) bool {
+	if errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
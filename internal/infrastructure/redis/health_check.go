@@ -0,0 +1,40 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HealthCheck implements port.HealthChecker by sending PING to Redis
+type HealthCheck struct {
+	client  redis.UniversalClient
+	timeout time.Duration
+}
+
+// NewHealthCheck creates a new Redis health check with the given timeout
+func NewHealthCheck(client redis.UniversalClient, timeout time.Duration) *HealthCheck {
+	return &HealthCheck{
+		client:  client,
+		timeout: timeout,
+	}
+}
+
+// Name returns the component name reported by the readiness probe
+func (h *HealthCheck) Name() string {
+	return "redis"
+}
+
+// Critical reports that the API cannot serve traffic without Redis
+func (h *HealthCheck) Critical() bool {
+	return true
+}
+
+// Check sends PING to Redis
+func (h *HealthCheck) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	return h.client.Ping(ctx).Err()
+}
@@ -0,0 +1,145 @@
+package redis
+
+import (
+	"container/list"
+	"path"
+	"sync"
+	"time"
+)
+
+// lruEntry is a locally cached value and its absolute expiry
+type lruEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// lru is a bounded, TTL-limited least-recently-used cache of strings
+// It is safe for concurrent use
+//
+// gen is bumped by every removal so a value read from Redis before an
+// invalidation is not added back after it (see generation and add)
+type lru struct {
+	mu       sync.Mutex
+	size     int
+	ttl      time.Duration
+	gen      uint64
+	order    *list.List // front is most recently used
+	elements map[string]*list.Element
+	now      func() time.Time
+}
+
+// newLRU creates an lru holding at most size entries for at most ttl each
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:     size,
+		ttl:      ttl,
+		order:    list.New(),
+		elements: make(map[string]*list.Element, size),
+		now:      time.Now,
+	}
+}
+
+// get returns the live value for key and marks it most recently used
+func (c *lru) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.elements[key]
+	if !ok {
+		return "", false
+	}
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(el)
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// generation returns the current invalidation generation
+// Read it before fetching a value from Redis and pass it to add
+func (c *lru) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+// add stores value for key, evicting the least recently used entry when full
+// The value is dropped if anything was invalidated since gen was read
+// It reports whether an entry was evicted
+func (c *lru) add(key, value string, gen uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.gen != gen {
+		return false
+	}
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.elements[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+		return false
+	}
+
+	c.elements[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.order.Len() <= c.size {
+		return false
+	}
+	c.removeElement(c.order.Back())
+	return true
+}
+
+// remove deletes the given keys
+func (c *lru) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for _, key := range keys {
+		if el, ok := c.elements[key]; ok {
+			c.removeElement(el)
+		}
+	}
+}
+
+// removeMatching deletes keys matching a glob pattern
+// Patterns use path.Match syntax, which agrees with Redis globs for keys without '/'
+// A pattern path.Match rejects removes every key, since Redis may still have matched some
+func (c *lru) removeMatching(pattern string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if _, err := path.Match(pattern, ""); err != nil {
+		c.order.Init()
+		c.elements = make(map[string]*list.Element, c.size)
+		return
+	}
+	for key, el := range c.elements {
+		if ok, _ := path.Match(pattern, key); ok {
+			c.removeElement(el)
+		}
+	}
+}
+
+// purge deletes every entry
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.order.Init()
+	c.elements = make(map[string]*list.Element, c.size)
+}
+
+// removeElement unlinks an element; the caller must hold mu
+func (c *lru) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.elements, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newLRU(2, time.Minute)
+
+	if c.add("a", "1", c.generation()) || c.add("b", "2", c.generation()) {
+		t.Fatal("want no eviction below the size bound")
+	}
+	// Reading a makes b the least recently used
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("want a cached")
+	}
+	if !c.add("c", "3", c.generation()) {
+		t.Fatal("want an eviction above the size bound")
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.get(key); ok != want {
+			t.Fatalf("%s: want cached %v, got %v", key, want, ok)
+		}
+	}
+	if c.order.Len() != 2 || len(c.elements) != 2 {
+		t.Fatalf("want 2 entries, got %d", c.order.Len())
+	}
+}
+
+func TestLRUExpiresAfterTTL(t *testing.T) {
+	now := time.Now()
+	c := newLRU(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.add("a", "1", c.generation())
+	now = now.Add(59 * time.Second)
+	if v, ok := c.get("a"); !ok || v != "1" {
+		t.Fatalf("want 1 before the TTL, got %q, %v", v, ok)
+	}
+	now = now.Add(time.Second)
+	if _, ok := c.get("a"); ok {
+		t.Fatal("want a expired at the TTL")
+	}
+	if len(c.elements) != 0 {
+		t.Fatalf("want the expired entry removed, got %d entries", len(c.elements))
+	}
+}
+
+func TestLRUDropsFillsStartedBeforeInvalidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		invalidate func(c *lru)
+	}{
+		{"remove", func(c *lru) { c.remove("other") }},
+		{"removeMatching", func(c *lru) { c.removeMatching("other:*") }},
+		{"purge", func(c *lru) { c.purge() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRU(10, time.Minute)
+			gen := c.generation()
+			tt.invalidate(c)
+
+			c.add("a", "stale", gen)
+			if _, ok := c.get("a"); ok {
+				t.Fatal("want the stale fill dropped")
+			}
+			c.add("a", "fresh", c.generation())
+			if v, ok := c.get("a"); !ok || v != "fresh" {
+				t.Fatalf("want fresh, got %q, %v", v, ok)
+			}
+		})
+	}
+}
+
+func TestLRURemoveMatching(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		// path.Match semantics: unlike a Redis glob, * stops at '/'
+		{"prefix", "user:*", []string{"order:1", "users:list", "user:1/orders"}},
+		{"single character", "user:?", []string{"user:10", "order:1", "users:list", "user:1/orders"}},
+		{"character class", "user:[12]", []string{"user:10", "order:1", "users:list", "user:1/orders"}},
+		{"malformed pattern removes everything", "user:[", []string{}},
+	}
+
+	keys := []string{"user:1", "user:2", "user:10", "order:1", "users:list", "user:1/orders"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newLRU(10, time.Minute)
+			for _, key := range keys {
+				c.add(key, "v", c.generation())
+			}
+			c.removeMatching(tt.pattern)
+			if len(c.elements) != len(tt.want) {
+				t.Fatalf("want %v kept, got %d entries", tt.want, len(c.elements))
+			}
+			for _, key := range tt.want {
+				if _, ok := c.get(key); !ok {
+					t.Fatalf("want %s kept", key)
+				}
+			}
+		})
+	}
+}
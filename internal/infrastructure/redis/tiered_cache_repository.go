@@ -0,0 +1,301 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"github.com/redis/go-redis/v9"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// Cache tiers reported in the cache.tier span tag
+const (
+	tierLocal  = "local"
+	tierRemote = "redis"
+)
+
+// TieredOptions configures the local tier of a TieredCacheRepository
+type TieredOptions struct {
+	// Size is the maximum number of locally cached keys
+	Size int
+	// TTL bounds how long a key is served locally without asking Redis
+	// It is also the worst-case staleness if an invalidation message is lost
+	TTL time.Duration
+	// Channel is the Redis pub/sub channel used to broadcast invalidations
+	Channel string
+}
+
+// invalidation is the pub/sub message telling other replicas what to evict
+type invalidation struct {
+	Origin  string   `json:"origin"`
+	Keys    []string `json:"keys,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	All     bool     `json:"all,omitempty"`
+}
+
+// TieredCacheRepository implements port.CacheRepository with an in-process LRU
+// in front of another CacheRepository (Redis)
+//
+// Reads are served locally when possible. Every write or delete evicts the key
+// locally and publishes an invalidation so other API replicas evict it too
+type TieredCacheRepository struct {
+	remote  port.CacheRepository
+	client  redis.UniversalClient
+	pubsub  *redis.PubSub
+	done    chan struct{} // closed when subscribe returns
+	local   *lru
+	channel string
+	origin  string
+	logger  *slog.Logger
+	metrics port.Metrics
+}
+
+// NewTieredCacheRepository creates a two-tier cache over remote and starts
+// listening for invalidations on opts.Channel
+// Call Close to stop listening before closing client
+func NewTieredCacheRepository(remote port.CacheRepository, client redis.UniversalClient, opts TieredOptions, logger *slog.Logger, metrics port.Metrics) *TieredCacheRepository {
+	r := &TieredCacheRepository{
+		remote:  remote,
+		client:  client,
+		pubsub:  client.Subscribe(context.Background(), opts.Channel),
+		done:    make(chan struct{}),
+		local:   newLRU(opts.Size, opts.TTL),
+		channel: opts.Channel,
+		origin:  newOrigin(),
+		logger:  logger,
+		metrics: metrics,
+	}
+	go r.subscribe()
+	return r
+}
+
+// Close ends the invalidation subscription and waits for its goroutine to exit
+// The Redis client itself is left open
+func (r *TieredCacheRepository) Close() error {
+	err := r.pubsub.Close()
+	<-r.done
+	if err != nil {
+		return fmt.Errorf("failed to close cache invalidation subscription: %w", err)
+	}
+	return nil
+}
+
+// newOrigin returns a random ID identifying this replica's own messages
+func newOrigin() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// subscribe applies invalidations published by other replicas until Close
+// go-redis reconnects the subscription on its own; messages sent while
+// disconnected are lost, so local entries may be stale for up to TTL
+func (r *TieredCacheRepository) subscribe() {
+	defer close(r.done)
+
+	for msg := range r.pubsub.Channel() {
+		r.receive(msg.Channel, msg.Payload)
+	}
+}
+
+// receive applies one invalidation message, skipping this replica's own
+func (r *TieredCacheRepository) receive(channel, payload string) {
+	var inv invalidation
+	if err := json.Unmarshal([]byte(payload), &inv); err != nil {
+		r.logger.Warn("Ignoring malformed cache invalidation", "channel", channel, "error", err.Error())
+		return
+	}
+	if inv.Origin == r.origin {
+		return
+	}
+	r.evict(inv)
+	r.metrics.Incr("cache.local.invalidations_received")
+}
+
+// evict applies an invalidation to the local tier
+func (r *TieredCacheRepository) evict(inv invalidation) {
+	switch {
+	case inv.All:
+		r.local.purge()
+	case inv.Pattern != "":
+		r.local.removeMatching(inv.Pattern)
+	default:
+		r.local.remove(inv.Keys...)
+	}
+}
+
+// invalidate evicts locally and broadcasts to the other replicas
+// A failed publish is logged and counted but does not fail the caller:
+// the write already reached Redis and remote copies expire after TTL
+func (r *TieredCacheRepository) invalidate(ctx context.Context, inv invalidation) {
+	r.evict(inv)
+
+	inv.Origin = r.origin
+	payload, _ := json.Marshal(inv)
+	if err := r.client.Publish(ctx, r.channel, payload).Err(); err != nil {
+		r.metrics.Incr("cache.local.invalidation_errors")
+		r.logger.ErrorContext(ctx, "Failed to publish cache invalidation",
+			"channel", r.channel, "error", err.Error())
+	}
+}
+
+// startTieredSpan starts a span for the tiered cache with the common metadata
+func startTieredSpan(ctx context.Context, operationName string) (ddtrace.Span, context.Context) {
+	span, ctx := tracer.StartSpanFromContext(ctx, operationName)
+	span.SetTag("cache.tiered", true)
+	return span, ctx
+}
+
+// Get serves key from the local tier, falling back to Redis and filling the local tier
+func (r *TieredCacheRepository) Get(ctx context.Context, key string) (string, error) {
+	span, ctx := startTieredSpan(ctx, "cache.get")
+	defer span.Finish()
+
+	span.SetTag("cache.key", key)
+
+	if value, ok := r.local.get(key); ok {
+		span.SetTag("cache.hit", true)
+		span.SetTag("cache.tier", tierLocal)
+		r.metrics.Incr("cache.local.hit")
+		return value, nil
+	}
+	r.metrics.Incr("cache.local.miss")
+
+	gen := r.local.generation()
+	value, err := r.remote.Get(ctx, key)
+	if err != nil {
+		span.SetTag("cache.hit", false)
+		if !errors.Is(err, port.ErrCacheMiss) {
+			span.SetTag("error", true)
+			span.SetTag("error.msg", err.Error())
+		}
+		return "", err
+	}
+
+	span.SetTag("cache.hit", true)
+	span.SetTag("cache.tier", tierRemote)
+	if r.local.add(key, value, gen) {
+		r.metrics.Incr("cache.local.evictions")
+	}
+	return value, nil
+}
+
+// MGet serves what it can locally and reads the remaining keys from Redis in one call
+func (r *TieredCacheRepository) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	span, ctx := startTieredSpan(ctx, "cache.mget")
+	defer span.Finish()
+
+	span.SetTag("cache.keys.count", len(keys))
+
+	values := make(map[string]string, len(keys))
+	var missing []string
+	for _, key := range keys {
+		if value, ok := r.local.get(key); ok {
+			values[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	span.SetTag("cache.local_hits", len(values))
+	r.metrics.Count("cache.local.hit", int64(len(values)))
+	r.metrics.Count("cache.local.miss", int64(len(missing)))
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	gen := r.local.generation()
+	remote, err := r.remote.MGet(ctx, missing...)
+	if err != nil {
+		span.SetTag("error", true)
+		span.SetTag("error.msg", err.Error())
+		return nil, err
+	}
+	span.SetTag("cache.remote_hits", len(remote))
+
+	for key, value := range remote {
+		values[key] = value
+		if r.local.add(key, value, gen) {
+			r.metrics.Incr("cache.local.evictions")
+		}
+	}
+	return values, nil
+}
+
+// Set writes through to Redis and invalidates the key on every replica
+func (r *TieredCacheRepository) Set(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) error {
+	if err := r.remote.Set(ctx, key, value, opts...); err != nil {
+		r.local.remove(key)
+		return err
+	}
+	r.invalidate(ctx, invalidation{Keys: []string{key}})
+	return nil
+}
+
+// SetNX writes through to Redis and invalidates the key on every replica when stored
+func (r *TieredCacheRepository) SetNX(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) (bool, error) {
+	ok, err := r.remote.SetNX(ctx, key, value, opts...)
+	if ok {
+		r.invalidate(ctx, invalidation{Keys: []string{key}})
+	}
+	return ok, err
+}
+
+// MSet writes through to Redis and invalidates the keys on every replica
+func (r *TieredCacheRepository) MSet(ctx context.Context, values map[string]interface{}, opts ...port.CacheOption) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	if err := r.remote.MSet(ctx, values, opts...); err != nil {
+		r.local.remove(keys...)
+		return err
+	}
+	r.invalidate(ctx, invalidation{Keys: keys})
+	return nil
+}
+
+// Delete removes the key from Redis and from every replica's local tier
+func (r *TieredCacheRepository) Delete(ctx context.Context, key string) error {
+	err := r.remote.Delete(ctx, key)
+	r.invalidate(ctx, invalidation{Keys: []string{key}})
+	return err
+}
+
+// Expire updates the TTL in Redis; local copies are evicted so they never outlive it
+func (r *TieredCacheRepository) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := r.remote.Expire(ctx, key, ttl)
+	if ok {
+		r.invalidate(ctx, invalidation{Keys: []string{key}})
+	}
+	return ok, err
+}
+
+// TTL is always answered by Redis, which owns expiry
+func (r *TieredCacheRepository) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return r.remote.TTL(ctx, key)
+}
+
+// DeletePattern deletes matching keys in Redis and on every replica's local tier
+func (r *TieredCacheRepository) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	n, err := r.remote.DeletePattern(ctx, pattern)
+	r.invalidate(ctx, invalidation{Pattern: pattern})
+	return n, err
+}
+
+// InvalidateTag deletes tagged keys in Redis
+// The local tier does not track tags, so every replica purges it entirely
+func (r *TieredCacheRepository) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	n, err := r.remote.InvalidateTag(ctx, tag)
+	r.invalidate(ctx, invalidation{All: true})
+	return n, err
+}
@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/memory"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestTiered returns a TieredCacheRepository over a memory remote
+// Its Redis client points at a closed port, so publishes fail and are only logged
+func newTestTiered(t *testing.T) (*TieredCacheRepository, port.CacheRepository) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 50 * time.Millisecond})
+	remote := memory.NewCacheRepository(time.Minute)
+	r := NewTieredCacheRepository(remote, client, TieredOptions{Size: 10, TTL: time.Minute, Channel: "cache:invalidate"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)), metrics.NewNoopMetrics())
+	t.Cleanup(func() {
+		_ = r.Close()
+		_ = client.Close()
+	})
+	return r, remote
+}
+
+func invalidationPayload(t *testing.T, inv invalidation) string {
+	t.Helper()
+
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(payload)
+}
+
+func TestTieredCacheRepositoryReceive(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  func(r *TieredCacheRepository) string
+		wantNew bool
+	}{
+		{"own messages are ignored", func(r *TieredCacheRepository) string { return r.origin }, false},
+		{"other replicas' messages evict", func(*TieredCacheRepository) string { return "other" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			r, remote := newTestTiered(t)
+
+			if err := remote.Set(ctx, "user:1", "old"); err != nil {
+				t.Fatal(err)
+			}
+			if v, err := r.Get(ctx, "user:1"); err != nil || v != "old" {
+				t.Fatalf("want old, got %q, %v", v, err)
+			}
+			// Changed behind the local tier, as by another replica
+			if err := remote.Set(ctx, "user:1", "new"); err != nil {
+				t.Fatal(err)
+			}
+
+			r.receive(r.channel, invalidationPayload(t, invalidation{Origin: tt.origin(r), Keys: []string{"user:1"}}))
+
+			want := "old"
+			if tt.wantNew {
+				want = "new"
+			}
+			if v, err := r.Get(ctx, "user:1"); err != nil || v != want {
+				t.Fatalf("want %s, got %q, %v", want, v, err)
+			}
+		})
+	}
+}
+
+func TestTieredCacheRepositoryInvalidateTagPurgesLocalTier(t *testing.T) {
+	ctx := context.Background()
+	r, remote := newTestTiered(t)
+
+	if err := remote.Set(ctx, "user:1", "a", port.WithTags("users")); err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Set(ctx, "order:1", "b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"user:1", "order:1"} {
+		if _, err := r.Get(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := r.InvalidateTag(ctx, "users")
+	if err != nil || n != 1 {
+		t.Fatalf("want 1 key invalidated, got %d, %v", n, err)
+	}
+	// The local tier does not know tags, so untagged keys go too
+	if len(r.local.elements) != 0 {
+		t.Fatalf("want the local tier purged, got %d entries", len(r.local.elements))
+	}
+	if v, err := r.Get(ctx, "order:1"); err != nil || v != "b" {
+		t.Fatalf("want order:1 refilled from the remote tier, got %q, %v", v, err)
+	}
+}
+
+func TestTieredCacheRepositoryClose(t *testing.T) {
+	r, _ := newTestTiered(t)
+
+	done := make(chan struct{})
+	go func() {
+		_ = r.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("want Close to stop the subscription")
+	}
+}
@@ -2,90 +2,223 @@ package tracing
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 // CacheRepositoryTracer wraps a CacheRepository with tracing
+// Misses are tagged cache.hit=false; failures are tagged as span errors and counted in cache.errors
 type CacheRepositoryTracer struct {
-	repo port.CacheRepository
-	ttl  time.Duration
+	repo    port.CacheRepository
+	metrics port.Metrics
 }
 
 // NewCacheRepositoryTracer creates a new tracing decorator for CacheRepository
-func NewCacheRepositoryTracer(repo port.CacheRepository, ttl time.Duration) port.CacheRepository {
+func NewCacheRepositoryTracer(repo port.CacheRepository, metrics port.Metrics) port.CacheRepository {
 	return &CacheRepositoryTracer{
-		repo: repo,
-		ttl:  ttl,
+		repo:    repo,
+		metrics: metrics,
 	}
 }
 
-// Set wraps the Set method with tracing
-func (r *CacheRepositoryTracer) Set(ctx context.Context, key string, value interface{}) error {
-	span, ctx := tracer.StartSpanFromContext(ctx, "redis.set")
-	defer span.Finish()
+// errorType classifies a cache failure for span and metric tags
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, port.ErrCacheTimeout):
+		return "timeout"
+	case errors.Is(err, port.ErrCacheUnavailable):
+		return "connection"
+	default:
+		return "other"
+	}
+}
+
+// startSpan starts a redis span with the common metadata
+func startSpan(ctx context.Context, operationName, operation string) (ddtrace.Span, context.Context) {
+	span, ctx := tracer.StartSpanFromContext(ctx, operationName)
 
 	// Add metadata
 	span.SetTag("db.type", "redis")
-	span.SetTag("db.operation", "SET")
-	span.SetTag("cache.key", key)
-	span.SetTag("cache.ttl", r.ttl.Seconds())
+	span.SetTag("db.operation", operation)
+
+	return span, ctx
+}
 
-	err := r.repo.Set(ctx, key, value)
+// tagWriteOptions tags the per-call TTL and tags of a write
+func tagWriteOptions(span ddtrace.Span, opts []port.CacheOption) {
+	o := port.ApplyCacheOptions(opts...)
+	if o.TTL > 0 {
+		span.SetTag("cache.ttl", o.TTL.Seconds())
+	} else {
+		span.SetTag("cache.ttl_default", true)
+	}
+	if len(o.Tags) > 0 {
+		span.SetTag("cache.tags", o.Tags)
+	}
+}
+
+// finishWithError tags the span with the operation outcome and counts failures
+func (r *CacheRepositoryTracer) finishWithError(span ddtrace.Span, operation string, err error) {
 	if err != nil {
+		kind := errorType(err)
 		span.SetTag("error", true)
 		span.SetTag("error.msg", err.Error())
+		span.SetTag("error.type", kind)
 		span.SetTag("cache.success", false)
-		return err
+		r.metrics.Incr("cache.errors", "operation:"+operation, "error.type:"+kind)
+		return
 	}
-
 	span.SetTag("cache.success", true)
-	return nil
+}
+
+// finishLookup is finishWithError for reads, where ErrCacheMiss is a normal outcome
+func (r *CacheRepositoryTracer) finishLookup(span ddtrace.Span, operation string, err error) {
+	if errors.Is(err, port.ErrCacheMiss) {
+		span.SetTag("cache.hit", false)
+		span.SetTag("cache.success", true)
+		return
+	}
+	if err == nil {
+		span.SetTag("cache.hit", true)
+	}
+	r.finishWithError(span, operation, err)
+}
+
+// Set wraps the Set method with tracing
+func (r *CacheRepositoryTracer) Set(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) error {
+	span, ctx := startSpan(ctx, "redis.set", "SET")
+	defer span.Finish()
+
+	span.SetTag("cache.key", key)
+	tagWriteOptions(span, opts)
+
+	err := r.repo.Set(ctx, key, value, opts...)
+	r.finishWithError(span, "set", err)
+	return err
+}
+
+// SetNX wraps the SetNX method with tracing
+func (r *CacheRepositoryTracer) SetNX(ctx context.Context, key string, value interface{}, opts ...port.CacheOption) (bool, error) {
+	span, ctx := startSpan(ctx, "redis.setnx", "SETNX")
+	defer span.Finish()
+
+	span.SetTag("cache.key", key)
+	tagWriteOptions(span, opts)
+
+	ok, err := r.repo.SetNX(ctx, key, value, opts...)
+	span.SetTag("cache.stored", ok)
+	r.finishWithError(span, "setnx", err)
+	return ok, err
 }
 
 // Get wraps the Get method with tracing
 func (r *CacheRepositoryTracer) Get(ctx context.Context, key string) (string, error) {
-	span, ctx := tracer.StartSpanFromContext(ctx, "redis.get")
+	span, ctx := startSpan(ctx, "redis.get", "GET")
 	defer span.Finish()
 
-	// Add metadata
-	span.SetTag("db.type", "redis")
-	span.SetTag("db.operation", "GET")
 	span.SetTag("cache.key", key)
 
 	value, err := r.repo.Get(ctx, key)
-	if err != nil {
-		// Cache miss is not an error
-		span.SetTag("cache.hit", false)
-		span.SetTag("cache.success", true)
-		return "", err
+	r.finishLookup(span, "get", err)
+	return value, err
+}
+
+// MGet wraps the MGet method with tracing
+func (r *CacheRepositoryTracer) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	span, ctx := startSpan(ctx, "redis.mget", "MGET")
+	defer span.Finish()
+
+	span.SetTag("cache.keys.count", len(keys))
+
+	values, err := r.repo.MGet(ctx, keys...)
+	if err == nil {
+		span.SetTag("cache.hits", len(values))
+		span.SetTag("cache.misses", len(keys)-len(values))
 	}
+	r.finishWithError(span, "mget", err)
+	return values, err
+}
 
-	span.SetTag("cache.hit", true)
-	span.SetTag("cache.success", true)
-	return value, nil
+// MSet wraps the MSet method with tracing
+func (r *CacheRepositoryTracer) MSet(ctx context.Context, values map[string]interface{}, opts ...port.CacheOption) error {
+	span, ctx := startSpan(ctx, "redis.mset", "MSET")
+	defer span.Finish()
+
+	span.SetTag("cache.keys.count", len(values))
+	tagWriteOptions(span, opts)
+
+	err := r.repo.MSet(ctx, values, opts...)
+	r.finishWithError(span, "mset", err)
+	return err
 }
 
 // Delete wraps the Delete method with tracing
 func (r *CacheRepositoryTracer) Delete(ctx context.Context, key string) error {
-	span, ctx := tracer.StartSpanFromContext(ctx, "redis.delete")
+	span, ctx := startSpan(ctx, "redis.delete", "DELETE")
 	defer span.Finish()
 
-	// Add metadata
-	span.SetTag("db.type", "redis")
-	span.SetTag("db.operation", "DELETE")
 	span.SetTag("cache.key", key)
 
 	err := r.repo.Delete(ctx, key)
-	if err != nil {
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		span.SetTag("cache.success", false)
-		return err
+	r.finishWithError(span, "delete", err)
+	return err
+}
+
+// Expire wraps the Expire method with tracing
+func (r *CacheRepositoryTracer) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	span, ctx := startSpan(ctx, "redis.expire", "EXPIRE")
+	defer span.Finish()
+
+	span.SetTag("cache.key", key)
+	span.SetTag("cache.ttl", ttl.Seconds())
+
+	ok, err := r.repo.Expire(ctx, key, ttl)
+	span.SetTag("cache.key_exists", ok)
+	r.finishWithError(span, "expire", err)
+	return ok, err
+}
+
+// TTL wraps the TTL method with tracing
+func (r *CacheRepositoryTracer) TTL(ctx context.Context, key string) (time.Duration, error) {
+	span, ctx := startSpan(ctx, "redis.ttl", "TTL")
+	defer span.Finish()
+
+	span.SetTag("cache.key", key)
+
+	ttl, err := r.repo.TTL(ctx, key)
+	if err == nil {
+		span.SetTag("cache.remaining_ttl", ttl.Seconds())
 	}
+	r.finishLookup(span, "ttl", err)
+	return ttl, err
+}
 
-	span.SetTag("cache.success", true)
-	return nil
+// DeletePattern wraps the DeletePattern method with tracing
+func (r *CacheRepositoryTracer) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	span, ctx := startSpan(ctx, "redis.delete_pattern", "SCAN+UNLINK")
+	defer span.Finish()
+
+	span.SetTag("cache.pattern", pattern)
+
+	n, err := r.repo.DeletePattern(ctx, pattern)
+	span.SetTag("cache.deleted", n)
+	r.finishWithError(span, "delete_pattern", err)
+	return n, err
+}
+
+// InvalidateTag wraps the InvalidateTag method with tracing
+func (r *CacheRepositoryTracer) InvalidateTag(ctx context.Context, tag string) (int64, error) {
+	span, ctx := startSpan(ctx, "redis.invalidate_tag", "SMEMBERS+UNLINK")
+	defer span.Finish()
+
+	span.SetTag("cache.tag", tag)
+
+	n, err := r.repo.InvalidateTag(ctx, tag)
+	span.SetTag("cache.deleted", n)
+	r.finishWithError(span, "invalidate_tag", err)
+	return n, err
 }
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// sqlStatsSorts orders SQL stats for GET /admin/sql/stats, highest first
+var sqlStatsSorts = map[string]func(a, b port.QueryStat) bool{
+	"total":  func(a, b port.QueryStat) bool { return a.TotalMs > b.TotalMs },
+	"count":  func(a, b port.QueryStat) bool { return a.Count > b.Count },
+	"p99":    func(a, b port.QueryStat) bool { return a.P99Ms > b.P99Ms },
+	"errors": func(a, b port.QueryStat) bool { return a.Errors > b.Errors },
+}
+
+// AdminHandler handles operational endpoints under /admin
+type AdminHandler struct{}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// SQLStats handles GET /admin/sql/stats
+// Returns per-fingerprint SQL statistics, sorted by sort (total|count|p99|errors,
+// default total) and truncated to limit; the list is empty with STORAGE=memory
+func (h *AdminHandler) SQLStats(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.sql_stats")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	sortBy := c.QueryParam("sort")
+	if sortBy == "" {
+		sortBy = "total"
+	}
+	less, ok := sqlStatsSorts[sortBy]
+	if !ok {
+		problem := response.NewValidationErrorProblem("sort must be one of total, count, p99, errors", c.Request().URL.Path)
+		problem.Extra["provided_sort"] = sortBy
+		span.SetTag("error", true)
+		span.SetTag("error.msg", problem.Detail)
+		return response.RenderProblem(c, problem)
+	}
+
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n <= 0 {
+			problem := response.NewValidationErrorProblem("limit must be a positive integer", c.Request().URL.Path)
+			problem.Extra["provided_limit"] = limitStr
+			span.SetTag("error", true)
+			span.SetTag("error.msg", problem.Detail)
+			return response.RenderProblem(c, problem)
+		}
+		limit = n
+	}
+
+	stats := []port.QueryStat{}
+	if repoLocator != nil && repoLocator.QueryStats != nil {
+		stats = repoLocator.QueryStats.Snapshot()
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return less(stats[i], stats[j]) })
+	total := len(stats)
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+
+	// Add result metadata
+	span.SetTag("sql.fingerprints", total)
+
+	logging.LogWithTrace(ctx, logger, "handler", "SQL stats retrieved successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"data":    stats,
+		"total":   total,
+		"sort":    sortBy,
+	})
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+// Handlers return errors instead of rendering them: middleware.EchoErrorHandler
+// logs, tags the request span and renders the problem once, with the status,
+// type and error.notify taken from the apperror catalog
+//
+// Use case errors are wrapped as apperror.KindInternal with the failed
+// operation; a more specific kind in the cause (not found, conflict) still
+// decides the response, and the message is only shown for internal errors
+
+// parseID reads the "id" path parameter
+// resource names the ID in the validation message, e.g. "User"
+func parseID(c echo.Context, resource string) (int, error) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, apperror.New(apperror.KindValidation, resource+" ID must be a valid integer").
+			WithExtra("provided_id", idStr)
+	}
+	return id, nil
+}
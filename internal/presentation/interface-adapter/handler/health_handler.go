@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
@@ -17,6 +23,19 @@ func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{}
 }
 
+// ComponentStatus is the readiness result for a single dependency
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+const (
+	componentUp   = "up"
+	componentDown = "down"
+)
+
 // HealthCheck handles GET /health
 func (h *HealthHandler) HealthCheck(c echo.Context) error {
 	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.health_check")
@@ -37,3 +56,108 @@ func (h *HealthHandler) HealthCheck(c echo.Context) error {
 		"message": "Service is healthy",
 	})
 }
+
+// Liveness handles GET /livez
+// It only reports that the process can serve HTTP; dependencies are not checked,
+// so an outage of MySQL or Redis never causes the orchestrator to restart the pod
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status": "ok",
+	})
+}
+
+// Readiness handles GET /readyz
+// All dependency checks run concurrently; each checker applies its own timeout.
+// A failing critical dependency returns 503, a failing optional one is reported as degraded
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.readiness")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	var checks []port.HealthChecker
+	if repoLocator != nil {
+		checks = repoLocator.HealthChecks
+	}
+
+	components := runHealthChecks(ctx, checks)
+
+	status := "ok"
+	var failed []string
+	for name, component := range components {
+		if component.Status == componentUp {
+			continue
+		}
+		if component.Critical {
+			failed = append(failed, name)
+			status = "unavailable"
+		} else if status == "ok" {
+			status = "degraded"
+		}
+	}
+	span.SetTag("health.status", status)
+
+	if len(failed) > 0 {
+		// The orchestrator polls this endpoint and reacts to the 503 itself;
+		// the failing dependency is alerted on where it is used, so do not page here
+		err := apperror.New(apperror.KindUnavailable, "Critical dependencies are unavailable")
+		logging.LogErrorWithTraceNotNotify(ctx, logger, "handler", "Readiness check failed", err, map[string]interface{}{
+			"health.failed": failed,
+		})
+
+		problem := response.NewProblemFromError(err, c.Request().URL.Path)
+		notify := false
+		problem.Notify = &notify
+		problem.Extra["components"] = components
+		return response.RenderProblem(c, problem)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"status":     status,
+		"components": components,
+	})
+}
+
+// runHealthChecks runs every check concurrently, each in its own child span
+func runHealthChecks(ctx context.Context, checks []port.HealthChecker) map[string]ComponentStatus {
+	var (
+		mu         sync.Mutex
+		wg         sync.WaitGroup
+		components = make(map[string]ComponentStatus, len(checks))
+	)
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check port.HealthChecker) {
+			defer wg.Done()
+
+			span, checkCtx := tracer.StartSpanFromContext(ctx, "health.check")
+			span.SetTag("component", check.Name())
+			span.SetTag("health.critical", check.Critical())
+
+			start := time.Now()
+			err := check.Check(checkCtx)
+			latency := time.Since(start)
+
+			result := ComponentStatus{
+				Status:    componentUp,
+				Critical:  check.Critical(),
+				LatencyMs: float64(latency.Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = componentDown
+				result.Error = err.Error()
+			}
+			span.SetTag("health.status", result.Status)
+			span.Finish(tracer.WithError(err))
+
+			mu.Lock()
+			components[check.Name()] = result
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	return components
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/validation"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// OrderHandler handles order-related HTTP requests
+type OrderHandler struct{}
+
+// NewOrderHandler creates a new OrderHandler
+func NewOrderHandler() *OrderHandler {
+	return &OrderHandler{}
+}
+
+// CreateOrderRequest represents the request body for creating an order
+type CreateOrderRequest struct {
+	UserID      int     `json:"user_id" validate:"required,gt=0"`
+	ProductName string  `json:"product_name" validate:"required,max=255"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// CreateOrder handles POST /api/orders
+func (h *OrderHandler) CreateOrder(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.create_order")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.OrderUseCase{
+		Logger:  logger,
+		RUser:   repoLocator.UserRepo,
+		ROrder:  repoLocator.OrderRepo,
+		Metrics: repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	var req CreateOrderRequest
+	fieldErrors, err := validation.DecodeJSON(c.Request().Body, &req)
+	if err != nil {
+		return validation.NewDecodeError(err)
+	}
+	if len(fieldErrors) > 0 {
+		span.SetTag("validation.error_count", len(fieldErrors))
+		return validation.NewError(fieldErrors)
+	}
+
+	// Add request data to span
+	span.SetTag("user.id", req.UserID)
+	span.SetTag("order.amount", req.Amount)
+
+	order, err := interactor.CreateOrder(ctx, req.UserID, req.ProductName, req.Amount)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to create order", err).
+			WithExtra("user.id", req.UserID)
+	}
+
+	// Add result to span
+	span.SetTag("order.id", order.ID)
+
+	logging.LogWithTrace(ctx, logger, "handler", "Order created successfully", nil)
+	return c.JSON(http.StatusCreated, map[string]any{
+		"success": true,
+		"data":    order,
+		"message": "Order created successfully",
+	})
+}
+
+// GetOrder handles GET /api/orders/{id}
+func (h *OrderHandler) GetOrder(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.get_order")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.OrderUseCase{
+		Logger:  logger,
+		RUser:   repoLocator.UserRepo,
+		ROrder:  repoLocator.OrderRepo,
+		Metrics: repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	id, err := parseID(c, "Order")
+	if err != nil {
+		return err
+	}
+
+	span.SetTag("order.id", id)
+
+	order, err := interactor.GetOrder(ctx, id)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to get order", err).
+			WithExtra("order.id", id)
+	}
+
+	logging.LogWithTrace(ctx, logger, "handler", "Order retrieved successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// GetAllOrders handles GET /api/orders
+func (h *OrderHandler) GetAllOrders(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.get_all_orders")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.OrderUseCase{
+		Logger:  logger,
+		RUser:   repoLocator.UserRepo,
+		ROrder:  repoLocator.OrderRepo,
+		Metrics: repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	orders, err := interactor.GetAllOrders(ctx)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to get orders", err)
+	}
+
+	// Add result metadata
+	span.SetTag("orders.count", len(orders))
+
+	logging.LogWithTrace(ctx, logger, "handler", "Orders retrieved successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"data":    orders,
+	})
+}
+
+// GetUserOrders handles GET /api/users/{id}/orders
+func (h *OrderHandler) GetUserOrders(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.get_user_orders")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.OrderUseCase{
+		Logger:  logger,
+		RUser:   repoLocator.UserRepo,
+		ROrder:  repoLocator.OrderRepo,
+		Metrics: repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	userID, err := parseID(c, "User")
+	if err != nil {
+		return err
+	}
+
+	span.SetTag("user.id", userID)
+
+	orders, err := interactor.GetUserOrders(ctx, userID)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to get user orders", err).
+			WithExtra("user.id", userID)
+	}
+
+	// Add result metadata
+	span.SetTag("orders.count", len(orders))
+
+	logging.LogWithTrace(ctx, logger, "handler", "User orders retrieved successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"data":    orders,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
 	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
@@ -22,22 +23,33 @@ func NewTestHandler() *TestHandler {
 }
 
 // SlowEndpoint handles GET /api/slow - demonstrates slow requests
+// It runs a real slow SELECT so slow-query logging and EXPLAIN capture can be observed
 func (h *TestHandler) SlowEndpoint(c echo.Context) error {
 	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.slow_endpoint")
 	defer span.Finish()
 
 	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
 
 	// Add request metadata to span
 	span.SetTag("http.method", c.Request().Method)
 	span.SetTag("http.url", c.Request().URL.Path)
 	span.SetTag("test.type", "slow_request")
 
-	logging.LogWithTrace(ctx, logger, "handler", "Slow endpoint called - simulating 2 second delay", nil)
+	logging.LogWithTrace(ctx, logger, "handler", "Slow endpoint called - running a 2 second query", nil)
+
+	interactor := &usecase.OrderUseCase{
+		Logger:  logger,
+		RUser:   repoLocator.UserRepo,
+		ROrder:  repoLocator.OrderRepo,
+		Metrics: repoLocator.Metrics,
+	}
 
-	// Simulate slow database query
-	span.SetTag("operation", "slow_query_simulation")
-	time.Sleep(2 * time.Second)
+	span.SetTag("operation", "slow_query")
+	matches, err := interactor.TestSlowQuery(ctx, 2*time.Second, "Laptop")
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Slow query failed", err)
+	}
 
 	logging.LogWithTrace(ctx, logger, "handler", "Slow operation completed", nil)
 
@@ -46,6 +58,7 @@ func (h *TestHandler) SlowEndpoint(c echo.Context) error {
 		"data": map[string]any{
 			"message": "This endpoint intentionally took 2 seconds to respond",
 			"delay":   "2s",
+			"matches": matches,
 		},
 		"message": "Slow request completed successfully",
 	})
@@ -83,7 +96,7 @@ func (h *TestHandler) ErrorEndpoint(c echo.Context) error {
 	)
 	problem.Extra["error.stack"] = "user_repository.go:42"
 	problem.Extra["db.operation"] = "connection_test"
-	return c.JSON(problem.Status, problem)
+	return response.RenderProblem(c, problem)
 }
 
 // ExpectedErrorEndpoint handles GET /api/expected-error - demonstrates expected error (no alert)
@@ -115,7 +128,7 @@ func (h *TestHandler) ExpectedErrorEndpoint(c echo.Context) error {
 	)
 	problem.Extra["user.email"] = "duplicate@example.com"
 	problem.Extra["validation.field"] = "email"
-	return c.JSON(problem.Status, problem)
+	return response.RenderProblem(c, problem)
 }
 
 // UnexpectedErrorEndpoint handles GET /api/unexpected-error - demonstrates unexpected error (should alert)
@@ -149,7 +162,7 @@ func (h *TestHandler) UnexpectedErrorEndpoint(c echo.Context) error {
 	problem.Extra["db.host"] = "mysql.example.com"
 	problem.Extra["db.port"] = 3306
 	problem.Extra["retry.attempted"] = false
-	return c.JSON(problem.Status, problem)
+	return response.RenderProblem(c, problem)
 }
 
 // WarnEndpoint handles GET /api/warn - demonstrates warning logs
@@ -201,9 +214,10 @@ func (h *TestHandler) PanicEndpoint(c echo.Context) error {
 
 	// Create usecase interactor
 	interactor := &usecase.UserUseCase{
-		Logger: logger,
-		RUser:  repoLocator.UserRepo,
-		RCache: repoLocator.CacheRepo,
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
 	}
 
 	// Call usecase method that will trigger panic in repository
@@ -219,7 +233,7 @@ func (h *TestHandler) PanicEndpoint(c echo.Context) error {
 			true,
 		)
 		problem.Extra["error"] = err.Error()
-		return c.JSON(problem.Status, problem)
+		return response.RenderProblem(c, problem)
 	}
 
 	// This line should never be reached due to panic
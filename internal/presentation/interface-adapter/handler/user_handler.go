@@ -1,13 +1,18 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
-	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/validation"
 	"github.com/kanehiroyuu/datadog-tour/internal/usecase"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -22,8 +27,24 @@ func NewUserHandler() *UserHandler {
 
 // CreateUserRequest represents the request body for creating a user
 type CreateUserRequest struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name  string `json:"name" validate:"required,max=255"`
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// CreateUserWithOrderRequest represents the request body for creating a user
+// together with their first order
+type CreateUserWithOrderRequest struct {
+	Name        string  `json:"name" validate:"required,max=255"`
+	Email       string  `json:"email" validate:"required,email,max=255"`
+	ProductName string  `json:"product_name" validate:"required,max=255"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+}
+
+// UpdateUserRequest represents the request body for updating a user
+// Fields omitted from the body are left unchanged on PATCH and required on PUT
+type UpdateUserRequest struct {
+	Name  *string `json:"name" validate:"notblank,max=255"`
+	Email *string `json:"email" validate:"notblank,email,max=255"`
 }
 
 // CreateUser handles POST /api/users
@@ -39,9 +60,10 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 	repoLocator := appcontext.GetRepoLocator(ctx)
 
 	interactor := &usecase.UserUseCase{
-		Logger: logger,
-		RUser:  repoLocator.UserRepo,
-		RCache: repoLocator.CacheRepo,
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
 	}
 
 	// Add request metadata to span
@@ -50,35 +72,23 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 	span.SetTag("http.user_agent", c.Request().UserAgent())
 
 	var req CreateUserRequest
-	if err := c.Bind(&req); err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "handler", "Failed to decode request body", err, nil)
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		problem := response.NewValidationErrorProblem(
-			"Request body is not valid JSON or does not match expected schema",
-			c.Request().URL.Path,
-		)
-		problem.Extra["parse_error"] = err.Error()
-		return c.JSON(problem.Status, problem)
+	fieldErrors, err := validation.DecodeJSON(c.Request().Body, &req)
+	if err != nil {
+		return validation.NewDecodeError(err)
+	}
+	if len(fieldErrors) > 0 {
+		span.SetTag("validation.error_count", len(fieldErrors))
+		return validation.NewError(fieldErrors)
 	}
 
 	// Add request data to span
-	span.SetTag("user.name", req.Name)
-	span.SetTag("user.email", req.Email)
+	redact.SetTag(span, "user.name", req.Name)
+	redact.SetTag(span, "user.email", req.Email)
 
 	user, err := interactor.CreateUser(ctx, req.Name, req.Email)
 	if err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "handler", "Failed to create user", err, nil)
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		problem := response.NewInternalErrorProblem(
-			"Failed to create user due to internal error",
-			c.Request().URL.Path,
-			true,
-		)
-		problem.Extra["user.email"] = req.Email
-		problem.Extra["error"] = err.Error()
-		return c.JSON(problem.Status, problem)
+		return apperror.Wrap(apperror.KindInternal, "Failed to create user", err).
+			WithExtra("user.email", req.Email)
 	}
 
 	// Add result to span
@@ -92,6 +102,61 @@ func (h *UserHandler) CreateUser(c echo.Context) error {
 	})
 }
 
+// CreateUserWithOrder handles POST /api/users/with-order
+// The user and the order are written in one transaction
+func (h *UserHandler) CreateUserWithOrder(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.create_user_with_order")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.UserUseCase{
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UoW:       repoLocator.UnitOfWork,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
+	}
+
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	var req CreateUserWithOrderRequest
+	fieldErrors, err := validation.DecodeJSON(c.Request().Body, &req)
+	if err != nil {
+		return validation.NewDecodeError(err)
+	}
+	if len(fieldErrors) > 0 {
+		span.SetTag("validation.error_count", len(fieldErrors))
+		return validation.NewError(fieldErrors)
+	}
+
+	redact.SetTag(span, "user.name", req.Name)
+	redact.SetTag(span, "user.email", req.Email)
+	span.SetTag("order.amount", req.Amount)
+
+	user, order, err := interactor.CreateUserWithOrder(ctx, req.Name, req.Email, req.ProductName, req.Amount)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to create user with order", err).
+			WithExtra("user.email", req.Email)
+	}
+
+	span.SetTag("user.id", user.ID)
+	span.SetTag("order.id", order.ID)
+
+	logging.LogWithTrace(ctx, logger, "handler", "User and order created successfully", nil)
+	return c.JSON(http.StatusCreated, map[string]any{
+		"success": true,
+		"data": map[string]any{
+			"user":  user,
+			"order": order,
+		},
+		"message": "User and order created successfully",
+	})
+}
+
 // GetUser handles GET /api/users/{id}
 func (h *UserHandler) GetUser(c echo.Context) error {
 	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.get_user")
@@ -101,9 +166,10 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 	repoLocator := appcontext.GetRepoLocator(ctx)
 
 	interactor := &usecase.UserUseCase{
-		Logger: logger,
-		RUser:  repoLocator.UserRepo,
-		RCache: repoLocator.CacheRepo,
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
 	}
 
 	// Add request metadata to span
@@ -111,38 +177,22 @@ func (h *UserHandler) GetUser(c echo.Context) error {
 	span.SetTag("http.url", c.Request().URL.Path)
 	span.SetTag("http.user_agent", c.Request().UserAgent())
 
-	idStr := c.Param("id")
-
-	id, err := strconv.Atoi(idStr)
+	id, err := parseID(c, "User")
 	if err != nil {
-		span.SetTag("error", true)
-		span.SetTag("error.msg", "Invalid user ID")
-		problem := response.NewValidationErrorProblem(
-			"User ID must be a valid integer",
-			c.Request().URL.Path,
-		)
-		problem.Extra["provided_id"] = idStr
-		return c.JSON(problem.Status, problem)
+		return err
 	}
 
 	span.SetTag("user.id", id)
 
 	user, err := interactor.GetUser(ctx, id)
 	if err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "handler", "Failed to get user", err, nil)
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		problem := response.NewNotFoundProblem(
-			"User with the specified ID does not exist",
-			c.Request().URL.Path,
-		)
-		problem.Extra["user.id"] = id
-		return c.JSON(problem.Status, problem)
+		return apperror.Wrap(apperror.KindInternal, "Failed to get user", err).
+			WithExtra("user.id", id)
 	}
 
 	// Add result metadata
-	span.SetTag("user.name", user.Name)
-	span.SetTag("user.email", user.Email)
+	redact.SetTag(span, "user.name", user.Name)
+	redact.SetTag(span, "user.email", user.Email)
 
 	logging.LogWithTrace(ctx, logger, "handler", "User retrieved successfully", nil)
 	return c.JSON(http.StatusOK, map[string]any{
@@ -160,9 +210,10 @@ func (h *UserHandler) GetAllUsers(c echo.Context) error {
 	repoLocator := appcontext.GetRepoLocator(ctx)
 
 	interactor := &usecase.UserUseCase{
-		Logger: logger,
-		RUser:  repoLocator.UserRepo,
-		RCache: repoLocator.CacheRepo,
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
 	}
 
 	// Add request metadata to span
@@ -170,26 +221,174 @@ func (h *UserHandler) GetAllUsers(c echo.Context) error {
 	span.SetTag("http.url", c.Request().URL.Path)
 	span.SetTag("http.user_agent", c.Request().UserAgent())
 
-	users, err := interactor.GetAllUsers(ctx)
+	input, err := parseListUsersInput(c)
 	if err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "handler", "Failed to get users", err, nil)
-		span.SetTag("error", true)
-		span.SetTag("error.msg", err.Error())
-		problem := response.NewInternalErrorProblem(
-			"Failed to retrieve users from database",
-			c.Request().URL.Path,
-			true,
-		)
-		problem.Extra["error"] = err.Error()
-		return c.JSON(problem.Status, problem)
+		return err
+	}
+
+	users, nextCursor, err := interactor.GetAllUsers(ctx, input)
+	if err != nil {
+		appErr := apperror.Wrap(apperror.KindInternal, "Failed to get users", err)
+		if errors.Is(err, usecase.ErrInvalidCursor) {
+			appErr.WithExtra("provided_cursor", input.Cursor)
+		}
+		return appErr
 	}
 
 	// Add result metadata
 	span.SetTag("users.count", len(users))
 
+	var next any
+	if nextCursor != "" {
+		next = nextCursor
+	}
+
 	logging.LogWithTrace(ctx, logger, "handler", "Users retrieved successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success":     true,
+		"data":        users,
+		"next_cursor": next,
+	})
+}
+
+// parseListUsersInput reads limit, cursor and filter query parameters
+// Returns a validation error when a parameter is malformed
+func parseListUsersInput(c echo.Context) (usecase.ListUsersInput, error) {
+	input := usecase.ListUsersInput{
+		Cursor:      c.QueryParam("cursor"),
+		EmailPrefix: c.QueryParam("email_prefix"),
+	}
+
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return input, apperror.New(apperror.KindValidation,
+				fmt.Sprintf("limit must be a positive integer (max %d)", usecase.MaxPageLimit)).
+				WithExtra("provided_limit", limitStr)
+		}
+		input.Limit = limit
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &input.CreatedAfter},
+		{"created_before", &input.CreatedBefore},
+	} {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return input, apperror.New(apperror.KindValidation,
+				param.name+" must be an RFC 3339 timestamp (e.g. 2024-01-02T15:04:05Z)").
+				WithExtra("provided_"+param.name, value)
+		}
+		*param.target = &t
+	}
+
+	return input, nil
+}
+
+// UpdateUser handles PUT /api/users/{id} and PATCH /api/users/{id}
+func (h *UserHandler) UpdateUser(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.update_user")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.UserUseCase{
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	id, err := parseID(c, "User")
+	if err != nil {
+		return err
+	}
+
+	span.SetTag("user.id", id)
+
+	var req UpdateUserRequest
+	fieldErrors, err := validation.DecodeJSON(c.Request().Body, &req)
+	if err != nil {
+		return validation.NewDecodeError(err)
+	}
+
+	// PUT replaces the whole resource, so every field is required
+	if c.Request().Method == http.MethodPut {
+		if req.Name == nil {
+			fieldErrors = append(fieldErrors, validation.FieldError{Pointer: "/name", Detail: "is required"})
+		}
+		if req.Email == nil {
+			fieldErrors = append(fieldErrors, validation.FieldError{Pointer: "/email", Detail: "is required"})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		span.SetTag("validation.error_count", len(fieldErrors))
+		return validation.NewError(fieldErrors)
+	}
+
+	user, err := interactor.UpdateUser(ctx, id, req.Name, req.Email)
+	if err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to update user", err).
+			WithExtra("user.id", id)
+	}
+
+	logging.LogWithTrace(ctx, logger, "handler", "User updated successfully", nil)
+	return c.JSON(http.StatusOK, map[string]any{
+		"success": true,
+		"data":    user,
+		"message": "User updated successfully",
+	})
+}
+
+// DeleteUser handles DELETE /api/users/{id}
+func (h *UserHandler) DeleteUser(c echo.Context) error {
+	span, ctx := tracer.StartSpanFromContext(c.Request().Context(), "handler.delete_user")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+	repoLocator := appcontext.GetRepoLocator(ctx)
+
+	interactor := &usecase.UserUseCase{
+		Logger:    logger,
+		RUser:     repoLocator.UserRepo,
+		UserCache: repoLocator.UserCache,
+		Metrics:   repoLocator.Metrics,
+	}
+
+	// Add request metadata to span
+	span.SetTag("http.method", c.Request().Method)
+	span.SetTag("http.url", c.Request().URL.Path)
+	span.SetTag("http.user_agent", c.Request().UserAgent())
+
+	id, err := parseID(c, "User")
+	if err != nil {
+		return err
+	}
+
+	span.SetTag("user.id", id)
+
+	if err := interactor.DeleteUser(ctx, id); err != nil {
+		return apperror.Wrap(apperror.KindInternal, "Failed to delete user", err).
+			WithExtra("user.id", id)
+	}
+
+	logging.LogWithTrace(ctx, logger, "handler", "User deleted successfully", nil)
 	return c.JSON(http.StatusOK, map[string]any{
 		"success": true,
-		"data":    users,
+		"message": "User deleted successfully",
 	})
 }
@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/redact"
+	"github.com/labstack/echo/v4"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
@@ -30,16 +33,21 @@ type ProblemDetail struct {
 	Extra    map[string]interface{} `json:"-"`                  // Additional extension members
 }
 
+// ProblemContentType is the media type for RFC 9457 responses
+const ProblemContentType = "application/problem+json"
+
 // ErrorType defines standard error type URIs
+// The URIs are owned by the apperror catalog; these aliases keep handler code short
 const (
-	ErrorTypeValidation     = "https://datadog-tour.example.com/errors/validation"
-	ErrorTypeNotFound       = "https://datadog-tour.example.com/errors/not-found"
-	ErrorTypeConflict       = "https://datadog-tour.example.com/errors/conflict"
-	ErrorTypeUnauthorized   = "https://datadog-tour.example.com/errors/unauthorized"
-	ErrorTypeForbidden      = "https://datadog-tour.example.com/errors/forbidden"
-	ErrorTypeInternal       = "https://datadog-tour.example.com/errors/internal"
-	ErrorTypeBadRequest     = "https://datadog-tour.example.com/errors/bad-request"
-	ErrorTypeServiceUnavail = "https://datadog-tour.example.com/errors/service-unavailable"
+	ErrorTypeValidation     = apperror.TypeValidation
+	ErrorTypeNotFound       = apperror.TypeNotFound
+	ErrorTypeConflict       = apperror.TypeConflict
+	ErrorTypeUnauthorized   = apperror.TypeUnauthorized
+	ErrorTypeForbidden      = apperror.TypeForbidden
+	ErrorTypeInternal       = apperror.TypeInternal
+	ErrorTypeBadRequest     = apperror.TypeBadRequest
+	ErrorTypeServiceUnavail = apperror.TypeServiceUnavail
+	ErrorTypeMethodNotAllow = apperror.TypeMethodNotAllow
 )
 
 // RespondJSONWithTrace sends a JSON response with trace headers
@@ -73,6 +81,35 @@ func RespondSuccessWithTrace(ctx context.Context, w http.ResponseWriter, status
 
 // RespondProblemWithTrace sends an RFC 9457 Problem Details response with trace information
 func RespondProblemWithTrace(ctx context.Context, w http.ResponseWriter, problem ProblemDetail) {
+	problem = withTrace(ctx, w.Header(), problem)
+
+	// Set Content-Type as per RFC 9457
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// RenderProblem sends an RFC 9457 Problem Details response from an Echo handler
+// Use instead of c.JSON so that trace IDs, Extra members and the
+// application/problem+json content type are always present
+func RenderProblem(c echo.Context, problem ProblemDetail) error {
+	problem = withTrace(c.Request().Context(), c.Response().Header(), problem)
+	// Extras may carry request data such as user.email
+	problem.Extra = redact.Map(problem.Extra)
+
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(problem.Status)
+	}
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(problem.Status, ProblemContentType, data)
+}
+
+// withTrace fills TraceID/SpanID from the active span and sets trace headers
+func withTrace(ctx context.Context, header http.Header, problem ProblemDetail) ProblemDetail {
 	// Extract trace information from context
 	span, ok := tracer.SpanFromContext(ctx)
 	if ok {
@@ -85,29 +122,39 @@ func RespondProblemWithTrace(ctx context.Context, w http.ResponseWriter, problem
 		problem.SpanID = fmt.Sprintf("%d", spanID)
 
 		// Add trace headers to response
-		w.Header().Set("X-Datadog-Trace-Id", fmt.Sprintf("%d", traceID))
-		w.Header().Set("X-Datadog-Span-Id", fmt.Sprintf("%d", spanID))
-		w.Header().Set("X-Datadog-Parent-Id", fmt.Sprintf("%d", spanID))
+		header.Set("X-Datadog-Trace-Id", fmt.Sprintf("%d", traceID))
+		header.Set("X-Datadog-Span-Id", fmt.Sprintf("%d", spanID))
+		header.Set("X-Datadog-Parent-Id", fmt.Sprintf("%d", spanID))
 	}
+	return problem
+}
 
-	// Set Content-Type as per RFC 9457
-	w.Header().Set("Content-Type", "application/problem+json")
-	w.WriteHeader(problem.Status)
-
-	// Encode problem detail with extra fields
-	encoder := json.NewEncoder(w)
+// MarshalJSON encodes the problem with Extra members at the root level
+// as RFC 9457 extension members
+func (p ProblemDetail) MarshalJSON() ([]byte, error) {
+	// Alias drops the MarshalJSON method to avoid infinite recursion
+	type alias ProblemDetail
+	mainData, err := json.Marshal(alias(p))
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Extra) == 0 {
+		return mainData, nil
+	}
 
-	// Marshal main struct
-	mainData, _ := json.Marshal(problem)
 	var result map[string]interface{}
-	json.Unmarshal(mainData, &result)
+	if err := json.Unmarshal(mainData, &result); err != nil {
+		return nil, err
+	}
 
-	// Add extra fields to root level
-	for k, v := range problem.Extra {
-		result[k] = v
+	// Add extra fields to root level without overriding standard members
+	for k, v := range p.Extra {
+		if _, exists := result[k]; !exists {
+			result[k] = v
+		}
 	}
 
-	encoder.Encode(result)
+	return json.Marshal(result)
 }
 
 // NewProblemDetail creates a new ProblemDetail with common fields set
@@ -122,6 +169,23 @@ func NewProblemDetail(errorType, title string, status int, detail, instance stri
 	}
 }
 
+// NewProblemFromError creates a problem detail from the error catalog
+// Status, type, title and notify all come from the error's kind, so they
+// always agree with how logging.LogError reports the same error
+// Extra members attached with apperror.Error.WithExtra are carried over
+func NewProblemFromError(err error, instance string) ProblemDetail {
+	def, message := apperror.Resolve(err)
+	if message == "" {
+		message = "An unexpected error occurred"
+	}
+
+	problem := NewProblemDetail(def.Type, def.Title, def.Status, message, instance)
+	problem.Extra = apperror.Extras(err)
+	notify := def.Notify
+	problem.Notify = &notify
+	return problem
+}
+
 // NewInternalErrorProblem creates a problem detail for internal server errors
 func NewInternalErrorProblem(detail, instance string, notify bool) ProblemDetail {
 	problem := NewProblemDetail(
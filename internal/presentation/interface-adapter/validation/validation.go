@@ -0,0 +1,227 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+// FieldError describes a single invalid field in a request body
+// Pointer is a JSON Pointer (RFC 6901) to the offending member
+type FieldError struct {
+	Pointer string `json:"pointer"`
+	Detail  string `json:"detail"`
+}
+
+// DecodeJSON decodes a JSON request body into dst and validates it
+// Returns an error only when the body is not parseable JSON or exceeds the
+// size limit set by http.MaxBytesReader (a KindPayloadTooLarge apperror);
+// unknown fields, type mismatches and `validate` tag violations are returned
+// as field errors
+//
+// Supported rules in the `validate` struct tag:
+//
+//	required  value must be present and non-blank
+//	notblank  value may be omitted (nil pointer) but must not be blank when present
+//	email     value must be a bare e-mail address
+//	max=N     string length must not exceed N characters
+//	gt=N      number must be greater than N
+func DecodeJSON(r io.Reader, dst any) ([]FieldError, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, apperror.Wrap(apperror.KindPayloadTooLarge,
+				fmt.Sprintf("Request body must not exceed %d bytes", maxErr.Limit), err)
+		}
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	var fieldErrors []FieldError
+
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(body, dst); err != nil {
+		if !errors.As(err, &typeErr) {
+			return nil, err
+		}
+		fieldErrors = append(fieldErrors, FieldError{
+			Pointer: "/" + typeErr.Field,
+			Detail:  fmt.Sprintf("must be of type %s", typeErr.Type.String()),
+		})
+	}
+
+	fieldErrors = append(fieldErrors, unknownFields(raw, dst)...)
+
+	// Skip rule checks for members that already failed to decode
+	for _, fe := range Struct(dst) {
+		if typeErr == nil || fe.Pointer != "/"+typeErr.Field {
+			fieldErrors = append(fieldErrors, fe)
+		}
+	}
+
+	return fieldErrors, nil
+}
+
+// Struct validates a struct (or pointer to struct) against its `validate` tags
+func Struct(v any) []FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fieldErrors []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		pointer := "/" + jsonName(field)
+		if detail := checkRules(rv.Field(i), strings.Split(tag, ",")); detail != "" {
+			fieldErrors = append(fieldErrors, FieldError{Pointer: pointer, Detail: detail})
+		}
+	}
+
+	return fieldErrors
+}
+
+// NewError returns the validation error listing every field error
+func NewError(fieldErrors []FieldError) error {
+	return apperror.New(apperror.KindValidation, "Request body failed validation").
+		WithExtra("errors", fieldErrors)
+}
+
+// NewDecodeError returns the error to report for a DecodeJSON error: the 413
+// error as is when the body is too large, otherwise a validation error with the parse error
+func NewDecodeError(err error) error {
+	if def, _ := apperror.Resolve(err); def.Kind == apperror.KindPayloadTooLarge {
+		return err
+	}
+	return apperror.Wrap(apperror.KindValidation, "Request body is not valid JSON or does not match expected schema", err).
+		WithExtra("parse_error", err.Error())
+}
+
+// checkRules applies the rules to a field and returns the first violation
+func checkRules(v reflect.Value, rules []string) string {
+	// Nil pointers are only invalid when required (optional PATCH fields)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if contains(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+				return "is required"
+			}
+		case "notblank":
+			if v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+				return "must not be blank"
+			}
+		case "email":
+			if v.Kind() == reflect.String && v.String() != "" && !isEmail(v.String()) {
+				return "must be a valid email address"
+			}
+		case "max":
+			limit, _ := strconv.Atoi(arg)
+			if v.Kind() == reflect.String && utf8.RuneCountInString(v.String()) > limit {
+				return fmt.Sprintf("must be at most %d characters", limit)
+			}
+		case "gt":
+			limit, _ := strconv.ParseFloat(arg, 64)
+			if n, ok := toFloat(v); ok && n <= limit {
+				return fmt.Sprintf("must be greater than %s", arg)
+			}
+		}
+	}
+
+	return ""
+}
+
+// unknownFields reports body members that do not map to a field of dst
+func unknownFields(raw map[string]json.RawMessage, dst any) []FieldError {
+	known := make(map[string]bool)
+	rt := reflect.Indirect(reflect.ValueOf(dst)).Type()
+	for i := 0; i < rt.NumField(); i++ {
+		known[jsonName(rt.Field(i))] = true
+	}
+
+	var names []string
+	for name := range raw {
+		if !known[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fieldErrors := make([]FieldError, 0, len(names))
+	for _, name := range names {
+		fieldErrors = append(fieldErrors, FieldError{
+			Pointer: "/" + name,
+			Detail:  "is not a recognized field",
+		})
+	}
+
+	return fieldErrors
+}
+
+// jsonName returns the JSON member name for a struct field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// isEmail reports whether s is a bare address such as "john@example.com"
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && addr.Name == ""
+}
+
+// toFloat converts numeric values for comparison rules
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func contains(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package validation
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+func TestDecodeJSONBodyLimit(t *testing.T) {
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int // 0 means decoded without error
+	}{
+		{"within limit", `{"name":"a"}`, 0},
+		{"over limit", `{"name":"` + strings.Repeat("a", 64) + `"}`, http.StatusRequestEntityTooLarge},
+		{"malformed", `{"name":`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(tt.body)), 32)
+
+			var req request
+			_, err := DecodeJSON(body, &req)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("want no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want an error")
+			}
+			if def, _ := apperror.Resolve(NewDecodeError(err)); def.Status != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, def.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+// EchoAdminAuthMiddleware requires "Authorization: Bearer <token>" on admin endpoints
+// Other requests get a 401 problem; the token is compared in constant time
+func EchoAdminAuthMiddleware(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			provided, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+				return apperror.New(apperror.KindUnauthorized, "A valid admin bearer token is required")
+			}
+
+			return next(c)
+		}
+	}
+}
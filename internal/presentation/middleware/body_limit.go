@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+// EchoBodyLimitMiddleware caps request bodies at limit bytes
+// A declared Content-Length over the limit is rejected with a 413 problem up
+// front; otherwise the body is wrapped so reading past the limit fails and
+// validation.DecodeJSON reports it as 413 too
+func EchoBodyLimitMiddleware(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return apperror.New(apperror.KindPayloadTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", limit))
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+
+			return next(c)
+		}
+	}
+}
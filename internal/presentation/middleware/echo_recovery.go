@@ -9,6 +9,7 @@ import (
 
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
 	"github.com/labstack/echo/v4"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -73,10 +74,13 @@ func EchoRecoveryMiddleware() echo.MiddlewareFunc {
 						span.SetTag("error.notify", true)
 					}
 
-					// Return 500 Internal Server Error
-					c.JSON(500, map[string]string{
-						"error": "Internal Server Error",
-					})
+					// Return 500 Internal Server Error as RFC 9457 problem
+					problem := response.NewInternalErrorProblem(
+						"An unexpected error occurred while processing the request",
+						c.Request().URL.Path,
+						true,
+					)
+					response.RenderProblem(c, problem)
 				}
 			}()
 
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/presentation/interface-adapter/response"
+)
+
+// EchoErrorHandler renders every error reaching Echo as an RFC 9457 problem
+// Handlers may simply `return err`; the apperror catalog decides the status,
+// problem type and whether the error log alerts. Echo's own errors (unknown
+// route, method not allowed, bind failures) are brought into the catalog too
+// It is the one place that logs the error, tags the request span and renders
+// the problem, including members attached with apperror.Error.WithExtra
+func EchoErrorHandler() echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		ctx := c.Request().Context()
+		logger := appcontext.GetLogger(ctx)
+
+		status := 0
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			status = httpErr.Code
+			message := fmt.Sprintf("%v", httpErr.Message)
+			if httpErr.Internal != nil {
+				err = apperror.Wrap(apperror.KindFromStatus(status), message, httpErr.Internal)
+			} else {
+				err = apperror.New(apperror.KindFromStatus(status), message)
+			}
+		}
+
+		logging.LogError(ctx, logger, "handler", "Request failed", err, map[string]any{
+			"http.method": c.Request().Method,
+			"http.url":    c.Request().URL.Path,
+		})
+
+		problem := response.NewProblemFromError(err, c.Request().URL.Path)
+		if status != 0 {
+			// Keep Echo's exact status (e.g. 413, 415) even if the kind is broader
+			problem.Status = status
+		}
+
+		if renderErr := response.RenderProblem(c, problem); renderErr != nil {
+			logging.LogErrorWithTrace(ctx, logger, "handler", "Failed to render problem", renderErr, nil)
+		}
+	}
+}
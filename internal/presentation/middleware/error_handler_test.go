@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+)
+
+func TestEchoErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+		wantExtra  map[string]any
+	}{
+		{
+			name:       "a specific kind in the cause decides",
+			err:        apperror.Wrap(apperror.KindInternal, "Failed to get user", fmt.Errorf("failed to find user: %w", entities.ErrUserNotFound)).WithExtra("user.id", 7),
+			wantStatus: http.StatusNotFound,
+			wantDetail: entities.ErrUserNotFound.Message,
+			wantExtra:  map[string]any{"user.id": float64(7)},
+		},
+		{
+			name:       "internal errors show the handler message, not the cause",
+			err:        apperror.Wrap(apperror.KindInternal, "Failed to get users", errors.New("dial tcp: connection refused")),
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "Failed to get users",
+		},
+		{
+			name:       "validation errors carry their extras",
+			err:        apperror.New(apperror.KindValidation, "User ID must be a valid integer").WithExtra("provided_id", "abc"),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "User ID must be a valid integer",
+			wantExtra:  map[string]any{"provided_id": "abc"},
+		},
+		{
+			name:       "errors outside the catalog are hidden",
+			err:        errors.New("dial tcp: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "An unexpected error occurred",
+		},
+		{
+			name:       "echo errors keep their status",
+			err:        echo.NewHTTPError(http.StatusUnsupportedMediaType, "Unsupported Media Type"),
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantDetail: "Unsupported Media Type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/users/7", nil)
+			req = req.WithContext(appcontext.SetLogger(req.Context(), slog.New(slog.NewTextHandler(io.Discard, nil))))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			EchoErrorHandler()(tt.err, c)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("want status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["detail"] != tt.wantDetail {
+				t.Fatalf("want detail %q, got %v", tt.wantDetail, body["detail"])
+			}
+			for key, want := range tt.wantExtra {
+				if body[key] != want {
+					t.Fatalf("want %s %v, got %v", key, want, body[key])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+// EchoMetricsMiddleware emits per-route RED metrics (rate, errors, duration)
+// Metrics: http.requests, http.errors, http.request.duration
+// Tags: route (path template, not raw URL), method, status_code, status_class
+func EchoMetricsMiddleware(metrics port.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			startTime := time.Now()
+
+			// Returned errors are rendered later by Echo's error handler, after
+			// tracing has seen them, so resolve their status the same way it will
+			err := next(c)
+
+			duration := time.Since(startTime)
+			status := c.Response().Status
+			if err != nil && !c.Response().Committed {
+				status = errorStatus(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			tags := []string{
+				"route:" + route,
+				"method:" + c.Request().Method,
+				"status_code:" + strconv.Itoa(status),
+				"status_class:" + strconv.Itoa(status/100) + "xx",
+			}
+
+			metrics.Incr("http.requests", tags...)
+			if status >= 500 {
+				metrics.Incr("http.errors", tags...)
+			}
+			metrics.Timing("http.request.duration", duration, tags...)
+
+			return err
+		}
+	}
+}
+
+// errorStatus returns the status EchoErrorHandler renders for err
+func errorStatus(err error) int {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	def, _ := apperror.Resolve(err)
+	return def.Status
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+)
+
+// EchoReadYourWritesMiddleware gives each request its own read-your-writes scope
+// After the request writes to the MySQL primary, its later reads skip the replicas
+// so it always sees its own writes despite replication lag
+func EchoReadYourWritesMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := appcontext.WithReadYourWrites(c.Request().Context())
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
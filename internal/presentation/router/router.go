@@ -2,7 +2,6 @@ package router
 
 import (
 	"log/slog"
-	"os"
 
 	"github.com/labstack/echo/v4"
 	echotrace "github.com/DataDog/dd-trace-go/contrib/labstack/echo.v4/v2"
@@ -13,7 +12,7 @@ import (
 )
 
 // Setup configures all routes with Datadog tracing
-func Setup(userHandler *handler.UserHandler, healthHandler *handler.HealthHandler, testHandler *handler.TestHandler, logger interface{}, repoLocator interface{}) *echo.Echo {
+func Setup(userHandler *handler.UserHandler, orderHandler *handler.OrderHandler, healthHandler *handler.HealthHandler, testHandler *handler.TestHandler, adminHandler *handler.AdminHandler, adminToken string, maxBodyBytes int64, serviceName string, logger interface{}, repoLocator interface{}) *echo.Echo {
 	// Setup Echo with Datadog tracing
 	// ここでspanが作成され、以降のハンドラやミドルウェアで利用可能に
 	e := echo.New()
@@ -22,6 +21,9 @@ func Setup(userHandler *handler.UserHandler, healthHandler *handler.HealthHandle
 	e.HideBanner = true
 	e.HidePort = true
 
+	// Render errors returned from handlers as RFC 9457 problems
+	e.HTTPErrorHandler = middleware.EchoErrorHandler()
+
 	// Apply middlewares in order
 	// 1. Logger middleware - sets logger in context
 	if logger != nil {
@@ -33,23 +35,47 @@ func Setup(userHandler *handler.UserHandler, healthHandler *handler.HealthHandle
 		e.Use(middleware.EchoRepoLocatorMiddleware(repoLocator.(*appcontext.RepoLocator)))
 	}
 
-	// 3. Datadog tracing middleware
-	e.Use(echotrace.Middleware(echotrace.WithService(os.Getenv("DD_SERVICE"))))
+	// 3. Read-your-writes middleware - reads after a write in the same request use the MySQL primary
+	e.Use(middleware.EchoReadYourWritesMiddleware())
+
+	// 4. Datadog tracing middleware
+	e.Use(echotrace.Middleware(echotrace.WithService(serviceName)))
+
+	// 5. Metrics middleware - per-route request/error/duration metrics
+	// Placed outside recovery so recovered panics are counted as 500s
+	if repoLocator != nil {
+		e.Use(middleware.EchoMetricsMiddleware(repoLocator.(*appcontext.RepoLocator).Metrics))
+	}
 
-	// 4. Recovery middleware AFTER tracing so span is available
+	// 6. Recovery middleware AFTER tracing so span is available
 	e.Use(middleware.EchoRecoveryMiddleware())
 
-	// 5. CORS middleware with Datadog tracing
+	// 7. CORS middleware with Datadog tracing
 	e.Use(middleware.EchoCORSMiddleware())
 
+	// 8. Body limit middleware - request bodies over maxBodyBytes get 413
+	e.Use(middleware.EchoBodyLimitMiddleware(maxBodyBytes))
+
 	// Health endpoints
 	e.GET("/", healthHandler.HealthCheck)
 	e.GET("/health", healthHandler.HealthCheck)
+	e.GET("/livez", healthHandler.Liveness)
+	e.GET("/readyz", healthHandler.Readiness)
 
 	// User endpoints
 	e.POST("/api/users", userHandler.CreateUser)
+	e.POST("/api/users/with-order", userHandler.CreateUserWithOrder)
 	e.GET("/api/users", userHandler.GetAllUsers)
 	e.GET("/api/users/:id", userHandler.GetUser)
+	e.PUT("/api/users/:id", userHandler.UpdateUser)
+	e.PATCH("/api/users/:id", userHandler.UpdateUser)
+	e.DELETE("/api/users/:id", userHandler.DeleteUser)
+	e.GET("/api/users/:id/orders", orderHandler.GetUserOrders)
+
+	// Order endpoints
+	e.POST("/api/orders", orderHandler.CreateOrder)
+	e.GET("/api/orders", orderHandler.GetAllOrders)
+	e.GET("/api/orders/:id", orderHandler.GetOrder)
 
 	// Test endpoints for Datadog demonstration
 	e.GET("/api/slow", testHandler.SlowEndpoint)
@@ -59,5 +85,11 @@ func Setup(userHandler *handler.UserHandler, healthHandler *handler.HealthHandle
 	e.GET("/api/warn", testHandler.WarnEndpoint)
 	e.GET("/api/panic", testHandler.PanicEndpoint)
 
+	// Admin endpoints, only mounted when a token is configured
+	if adminToken != "" {
+		admin := e.Group("/admin", middleware.EchoAdminAuthMiddleware(adminToken))
+		admin.GET("/sql/stats", adminHandler.SQLStats)
+	}
+
 	return e
 }
@@ -0,0 +1,273 @@
+package cacheaside
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"golang.org/x/sync/singleflight"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// notFoundMarker is stored for negatively cached keys
+// It can never collide with a JSON-encoded value
+const notFoundMarker = "\x00not_found"
+
+// minJitteredTTL is the smallest TTL jitter returns; Redis expiry has millisecond precision
+const minJitteredTTL = time.Millisecond
+
+// Source tells where GetOrLoad obtained its result
+type Source string
+
+const (
+	// SourceCache means the value (or a cached not-found) was read from the cache
+	SourceCache Source = "cache"
+	// SourceLoader means this call ran the loader
+	SourceLoader Source = "loader"
+	// SourceShared means another in-flight call for the same key ran the loader
+	SourceShared Source = "shared"
+)
+
+// Options configures a Cache
+type Options struct {
+	// TTL is the base TTL of cached values
+	TTL time.Duration
+	// NegativeTTL is the base TTL of not-found markers; 0 disables negative caching
+	NegativeTTL time.Duration
+	// Jitter spreads expiry by ±Jitter*TTL (e.g. 0.1) so keys filled together do not expire together
+	Jitter float64
+	// NotFound is the error the loader returns for a missing entity
+	// It is negatively cached and returned on a negative cache hit
+	NotFound error
+}
+
+// Cache is a typed cache-aside helper on top of port.CacheRepository
+// Concurrent misses for the same key are collapsed into a single loader call
+// Cache is safe for concurrent use and is meant to be shared across requests
+//
+// gen is bumped by every Set and Invalidate so a value loaded before a write
+// is not cached after it (see GetOrLoad and store)
+type Cache[T any] struct {
+	repo    port.CacheRepository
+	metrics port.Metrics
+	logger  port.Logger
+	name    string
+	opts    Options
+	group   singleflight.Group
+	gen     atomic.Uint64
+}
+
+// New creates a new Cache; name prefixes keys and tags metrics and spans
+func New[T any](repo port.CacheRepository, metrics port.Metrics, logger port.Logger, name string, opts Options) *Cache[T] {
+	return &Cache[T]{
+		repo:    repo,
+		metrics: metrics,
+		logger:  logger,
+		name:    name,
+		opts:    opts,
+	}
+}
+
+// Key returns the cache key for an entity ID, e.g. "user:42"
+func (c *Cache[T]) Key(id any) string {
+	return fmt.Sprintf("%s:%v", c.name, id)
+}
+
+// loadResult is shared between callers of a single-flight load
+type loadResult[T any] struct {
+	value *T
+	err   error
+}
+
+// GetOrLoad returns the cached value for key, or calls load on a miss and caches the result
+// Cache failures never fail the call; they only degrade to loading from the source
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (*T, error)) (*T, Source, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "cache_aside.get_or_load")
+	defer span.Finish()
+
+	tag := "cache:" + c.name
+
+	span.SetTag("cache.name", c.name)
+	span.SetTag("cache.key", key)
+
+	if value, found, err := c.lookup(ctx, key); found {
+		span.SetTag("cache.hit", true)
+		span.SetTag("cache.source", string(SourceCache))
+		if err != nil {
+			span.SetTag("cache.negative", true)
+			c.metrics.Incr("cache.negative_hit", tag)
+		} else {
+			c.metrics.Incr("cache.hit", tag)
+		}
+		return value, SourceCache, err
+	}
+
+	c.metrics.Incr("cache.miss", tag)
+	span.SetTag("cache.hit", false)
+
+	// The loader runs detached from the first caller's cancellation,
+	// otherwise one aborted request would fail every caller waiting on it
+	loadCtx := context.WithoutCancel(ctx)
+	// singleflight also reports shared to the caller that ran the function,
+	// so whether this call was the loader is tracked separately
+	ran := false
+	v, _, _ := c.group.Do(key, func() (interface{}, error) {
+		ran = true
+		gen := c.gen.Load()
+		value, err := load(loadCtx)
+		c.store(loadCtx, key, value, err, gen)
+		return loadResult[T]{value: value, err: err}, nil
+	})
+	result := v.(loadResult[T])
+
+	source := SourceLoader
+	if !ran {
+		source = SourceShared
+		c.metrics.Incr("cache.load.shared", tag)
+		logging.LogWithTrace(ctx, c.logger, "usecase", "Joined in-flight cache load", map[string]any{
+			"cache.key": key,
+		})
+	}
+	span.SetTag("cache.source", string(source))
+
+	return result.value, source, result.err
+}
+
+// Set writes value through to the cache, replacing any negative entry for key
+// Loads in flight keep their result to themselves instead of overwriting it
+func (c *Cache[T]) Set(ctx context.Context, key string, value *T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value for cache: %w", err)
+	}
+	c.fence(key)
+	return c.repo.Set(ctx, key, string(data), port.WithTTL(c.jitter(c.opts.TTL)))
+}
+
+// Invalidate removes key from the cache, including a negative entry
+// Loads in flight do not cache their (possibly stale) result afterwards
+func (c *Cache[T]) Invalidate(ctx context.Context, key string) error {
+	c.fence(key)
+	return c.repo.Delete(ctx, key)
+}
+
+// fence bumps the generation and detaches the in-flight load of key, so
+// later misses start a fresh load instead of joining one that began before the write
+func (c *Cache[T]) fence(key string) {
+	c.gen.Add(1)
+	c.group.Forget(key)
+}
+
+// lookup reads key from the cache
+// found reports whether the cache answered; err is NotFound on a negative hit
+func (c *Cache[T]) lookup(ctx context.Context, key string) (*T, bool, error) {
+	data, err := c.repo.Get(ctx, key)
+	if errors.Is(err, port.ErrCacheMiss) {
+		return nil, false, nil
+	}
+	if err != nil {
+		// Degrade to the loader, but make the outage visible instead of looking like a cold cache
+		logging.LogErrorWithTraceNotNotify(ctx, c.logger, "usecase", "Cache unavailable, falling back to loader", err, map[string]any{
+			"cache.key": key,
+		})
+		return nil, false, nil
+	}
+	if data == "" {
+		return nil, false, nil
+	}
+
+	if data == notFoundMarker {
+		if c.opts.NotFound != nil {
+			return nil, true, c.opts.NotFound
+		}
+		return nil, false, nil
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		// Treat undecodable entries (e.g. after a schema change) as a miss; the load overwrites them
+		logging.LogWarnWithTrace(ctx, c.logger, "usecase", "Failed to decode cached value, reloading", map[string]any{
+			"cache.key": key,
+			"error":     err.Error(),
+		})
+		return nil, false, nil
+	}
+
+	return &value, true, nil
+}
+
+// store caches a loader result: the value, a not-found marker, or nothing on other errors
+// gen is the generation read before the load; nothing is cached if a Set or
+// Invalidate happened since, and an entry written concurrently with one is deleted again
+func (c *Cache[T]) store(ctx context.Context, key string, value *T, loadErr error, gen uint64) {
+	if c.gen.Load() != gen {
+		c.metrics.Incr("cache.store.stale", "cache:"+c.name)
+		return
+	}
+	defer c.dropIfStale(ctx, key, gen)
+
+	if loadErr != nil {
+		if c.opts.NegativeTTL <= 0 || c.opts.NotFound == nil || !errors.Is(loadErr, c.opts.NotFound) {
+			return
+		}
+		if err := c.repo.Set(ctx, key, notFoundMarker, port.WithTTL(c.jitter(c.opts.NegativeTTL))); err != nil {
+			logging.LogErrorWithTraceNotNotify(ctx, c.logger, "usecase", "Failed to set negative cache entry", err, map[string]any{
+				"cache.key": key,
+			})
+		}
+		return
+	}
+
+	if value == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logging.LogErrorWithTrace(ctx, c.logger, "usecase", "Failed to encode value for cache", err, map[string]any{
+			"cache.key": key,
+		})
+		return
+	}
+
+	if err := c.repo.Set(ctx, key, string(data), port.WithTTL(c.jitter(c.opts.TTL))); err != nil {
+		logging.LogErrorWithTraceNotNotify(ctx, c.logger, "usecase", "Failed to set cache entry", err, map[string]any{
+			"cache.key": key,
+		})
+	}
+}
+
+// dropIfStale deletes key when a Set or Invalidate raced with storing it
+// A dropped entry costs one extra miss; a kept stale one would live for a full TTL
+func (c *Cache[T]) dropIfStale(ctx context.Context, key string, gen uint64) {
+	if c.gen.Load() == gen {
+		return
+	}
+	c.metrics.Incr("cache.store.stale", "cache:"+c.name)
+	if err := c.repo.Delete(ctx, key); err != nil {
+		logging.LogErrorWithTraceNotNotify(ctx, c.logger, "usecase", "Failed to delete stale cache entry", err, map[string]any{
+			"cache.key": key,
+		})
+	}
+}
+
+// jitter returns ttl randomly adjusted by up to ±Jitter*ttl
+// The result is at least minJitteredTTL, so a large Jitter can neither make
+// the entry never expire (TTL 0) nor be rejected by the cache (negative TTL)
+func (c *Cache[T]) jitter(ttl time.Duration) time.Duration {
+	if c.opts.Jitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	spread := float64(ttl) * c.opts.Jitter
+	jittered := ttl + time.Duration((rand.Float64()*2-1)*spread)
+	if jittered < minJitteredTTL {
+		return minJitteredTTL
+	}
+	return jittered
+}
@@ -0,0 +1,265 @@
+package cacheaside
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/memory"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+)
+
+func TestJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		jitter   float64
+		min, max time.Duration
+	}{
+		{"disabled", time.Minute, 0, time.Minute, time.Minute},
+		{"ten percent", time.Minute, 0.1, 54 * time.Second, 66 * time.Second},
+		{"clamped to the minimum", time.Minute, 3, minJitteredTTL, 4 * time.Minute},
+		{"zero ttl is left alone", 0, 0.5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cache[struct{}]{opts: Options{Jitter: tt.jitter}}
+			for i := 0; i < 1000; i++ {
+				if got := c.jitter(tt.ttl); got < tt.min || got > tt.max {
+					t.Fatalf("want between %s and %s, got %s", tt.min, tt.max, got)
+				}
+			}
+		})
+	}
+}
+
+var (
+	errNotFound = errors.New("not found")
+	errBoom     = errors.New("boom")
+	errDown     = errors.New("cache down")
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+// failingGetRepo is a cache whose reads fail, as during an outage
+type failingGetRepo struct {
+	port.CacheRepository
+}
+
+func (failingGetRepo) Get(ctx context.Context, key string) (string, error) {
+	return "", errDown
+}
+
+// missCounter counts cache.miss increments
+type missCounter struct {
+	metrics.NoopMetrics
+	misses atomic.Int64
+}
+
+func (m *missCounter) Incr(name string, tags ...string) {
+	if name == "cache.miss" {
+		m.misses.Add(1)
+	}
+}
+
+func newTestCache(repo port.CacheRepository, m port.Metrics, opts Options) *Cache[item] {
+	return New[item](repo, m, slog.New(slog.NewTextHandler(io.Discard, nil)), "item", opts)
+}
+
+func TestGetOrLoad(t *testing.T) {
+	cached := Options{TTL: time.Minute, NegativeTTL: time.Minute, NotFound: errNotFound}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		failGet     bool
+		prefill     string
+		loadErr     error
+		wantSources []Source
+		wantErr     error
+		wantLoads   int
+	}{
+		{
+			name:        "value is cached",
+			opts:        cached,
+			wantSources: []Source{SourceLoader, SourceCache},
+			wantLoads:   1,
+		},
+		{
+			name:        "not found is negatively cached",
+			opts:        cached,
+			loadErr:     errNotFound,
+			wantSources: []Source{SourceLoader, SourceCache},
+			wantErr:     errNotFound,
+			wantLoads:   1,
+		},
+		{
+			name:        "zero NegativeTTL disables negative caching",
+			opts:        Options{TTL: time.Minute, NotFound: errNotFound},
+			loadErr:     errNotFound,
+			wantSources: []Source{SourceLoader, SourceLoader},
+			wantErr:     errNotFound,
+			wantLoads:   2,
+		},
+		{
+			name:        "other loader errors are not cached",
+			opts:        cached,
+			loadErr:     errBoom,
+			wantSources: []Source{SourceLoader, SourceLoader},
+			wantErr:     errBoom,
+			wantLoads:   2,
+		},
+		{
+			name:        "cache read errors fall back to the loader",
+			opts:        cached,
+			failGet:     true,
+			wantSources: []Source{SourceLoader, SourceLoader},
+			wantLoads:   2,
+		},
+		{
+			name:        "undecodable entries are reloaded",
+			opts:        cached,
+			prefill:     "{not json",
+			wantSources: []Source{SourceLoader, SourceCache},
+			wantLoads:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var repo port.CacheRepository = memory.NewCacheRepository(time.Minute)
+			if tt.failGet {
+				repo = failingGetRepo{repo}
+			}
+			c := newTestCache(repo, metrics.NewNoopMetrics(), tt.opts)
+			key := c.Key(1)
+			if tt.prefill != "" {
+				if err := repo.Set(ctx, key, tt.prefill); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			loads := 0
+			load := func(ctx context.Context) (*item, error) {
+				loads++
+				if tt.loadErr != nil {
+					return nil, tt.loadErr
+				}
+				return &item{Name: "alice"}, nil
+			}
+
+			for i, want := range tt.wantSources {
+				value, source, err := c.GetOrLoad(ctx, key, load)
+				if source != want {
+					t.Fatalf("call %d: want source %s, got %s", i, want, source)
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("call %d: want error %v, got %v", i, tt.wantErr, err)
+				}
+				if tt.wantErr == nil && (value == nil || value.Name != "alice") {
+					t.Fatalf("call %d: want alice, got %+v", i, value)
+				}
+			}
+			if loads != tt.wantLoads {
+				t.Fatalf("want %d loads, got %d", tt.wantLoads, loads)
+			}
+		})
+	}
+}
+
+func TestGetOrLoadSharesConcurrentMisses(t *testing.T) {
+	const callers = 8
+
+	m := &missCounter{}
+	c := newTestCache(memory.NewCacheRepository(time.Minute), m, Options{TTL: time.Minute})
+	key := c.Key(1)
+
+	var loads atomic.Int64
+	release := make(chan struct{})
+	load := func(ctx context.Context) (*item, error) {
+		loads.Add(1)
+		<-release
+		return &item{Name: "alice"}, nil
+	}
+
+	sources := make(chan Source, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, source, err := c.GetOrLoad(context.Background(), key, load)
+			if err != nil {
+				t.Error(err)
+			}
+			sources <- source
+		}()
+	}
+
+	// Every caller has missed; give the last ones time to join the flight
+	for m.misses.Load() < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(sources)
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("want 1 load, got %d", n)
+	}
+	counts := map[Source]int{}
+	for source := range sources {
+		counts[source]++
+	}
+	if counts[SourceLoader] != 1 || counts[SourceShared] != callers-1 {
+		t.Fatalf("want 1 loader and %d shared, got %v", callers-1, counts)
+	}
+}
+
+func TestGetOrLoadDropsValueInvalidatedDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewCacheRepository(time.Minute)
+	c := newTestCache(repo, metrics.NewNoopMetrics(), Options{TTL: time.Minute})
+	key := c.Key(1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _, _ = c.GetOrLoad(ctx, key, func(ctx context.Context) (*item, error) {
+			close(started)
+			<-release
+			return &item{Name: "stale"}, nil
+		})
+	}()
+
+	<-started
+	if err := c.Invalidate(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	<-done
+
+	if _, err := repo.Get(ctx, key); !errors.Is(err, port.ErrCacheMiss) {
+		t.Fatalf("want the stale value dropped, got %v", err)
+	}
+
+	value, source, err := c.GetOrLoad(ctx, key, func(ctx context.Context) (*item, error) {
+		return &item{Name: "fresh"}, nil
+	})
+	if err != nil || source != SourceLoader || value.Name != "fresh" {
+		t.Fatalf("want a fresh load, got %+v, %s, %v", value, source, err)
+	}
+}
@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// OrderUseCase implements order business logic
+type OrderUseCase struct {
+	Logger  port.Logger
+	RUser   port.UserRepository
+	ROrder  port.OrderRepository
+	Metrics port.Metrics
+}
+
+// CreateOrder creates a new pending order for an existing user
+func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID int, productName string, amount float64) (*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.create_order")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("user.id", userID)
+	span.SetTag("order.amount", amount)
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Creating order", map[string]any{
+		"user.id":            userID,
+		"order.product_name": productName,
+		"order.amount":       amount,
+	})
+
+	// Verify the owning user exists before inserting; read the primary so a
+	// user created by a just-completed request is not missing on a lagging replica
+	if _, err := uc.RUser.FindByID(appcontext.WithPrimaryReads(ctx), userID); err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to get order owner from repository", err, map[string]any{
+			"user.id": userID,
+		})
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	order := &entities.Order{
+		UserID:      userID,
+		ProductName: productName,
+		Amount:      amount,
+		Status:      entities.OrderStatusPending,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := uc.ROrder.Create(ctx, order); err != nil {
+		uc.Metrics.Incr("orders.create.error")
+		logging.LogError(ctx, logger, "usecase", "Failed to create order in repository", err, nil)
+		return nil, fmt.Errorf("failed to create order: %w", err)
+	}
+
+	uc.Metrics.Incr("orders.create.success")
+	uc.Metrics.Histogram("orders.amount", amount)
+
+	span.SetTag("order.id", order.ID)
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Order created successfully", map[string]any{
+		"order.id": order.ID,
+		"user.id":  userID,
+	})
+
+	return order, nil
+}
+
+// GetOrder retrieves an order by ID
+func (uc *OrderUseCase) GetOrder(ctx context.Context, id int) (*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.get_order")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("order.id", id)
+	span.SetTag("data.source", "database")
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Getting order by ID", map[string]any{
+		"order.id": id,
+	})
+
+	order, err := uc.ROrder.FindByID(ctx, id)
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to get order from repository", err, map[string]any{
+			"order.id": id,
+		})
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	span.SetTag("user.id", order.UserID)
+
+	return order, nil
+}
+
+// GetAllOrders retrieves all orders
+func (uc *OrderUseCase) GetAllOrders(ctx context.Context) ([]*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.get_all_orders")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("data.source", "database")
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Fetching all orders", nil)
+
+	orders, err := uc.ROrder.FindAll(ctx)
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to fetch orders from repository", err, nil)
+		return nil, fmt.Errorf("failed to get orders: %w", err)
+	}
+
+	span.SetTag("orders.count", len(orders))
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Orders fetched successfully", map[string]any{
+		"orders.count": len(orders),
+	})
+
+	return orders, nil
+}
+
+// GetUserOrders retrieves all orders for a user, verifying the user exists first
+func (uc *OrderUseCase) GetUserOrders(ctx context.Context, userID int) ([]*entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.get_user_orders")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("user.id", userID)
+	span.SetTag("data.source", "database")
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Fetching orders for user", map[string]any{
+		"user.id": userID,
+	})
+
+	if _, err := uc.RUser.FindByID(ctx, userID); err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to get user from repository", err, map[string]any{
+			"user.id": userID,
+		})
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	orders, err := uc.ROrder.FindByUserID(ctx, userID)
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to fetch user orders from repository", err, map[string]any{
+			"user.id": userID,
+		})
+		return nil, fmt.Errorf("failed to get user orders: %w", err)
+	}
+
+	span.SetTag("orders.count", len(orders))
+
+	logging.LogWithTrace(ctx, logger, "usecase", "User orders fetched successfully", map[string]any{
+		"user.id":      userID,
+		"orders.count": len(orders),
+	})
+
+	return orders, nil
+}
+
+// TestSlowQuery runs a deliberately slow query for testing slow-query detection
+func (uc *OrderUseCase) TestSlowQuery(ctx context.Context, delay time.Duration, term string) (int, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.test_slow_query")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("test.type", "slow_query")
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Running slow query", map[string]any{
+		"test.delay_ms": delay.Milliseconds(),
+		"test.term":     term,
+	})
+
+	matches, err := uc.ROrder.TestSlowQuery(ctx, delay, term)
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Slow query failed", err, nil)
+		return 0, fmt.Errorf("failed to run slow query: %w", err)
+	}
+
+	return matches, nil
+}
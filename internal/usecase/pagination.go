@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
+)
+
+const (
+	// DefaultPageLimit is used when the caller does not specify a limit
+	DefaultPageLimit = 20
+	// MaxPageLimit caps the page size to protect the database
+	MaxPageLimit = 100
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = apperror.New(apperror.KindValidation, "cursor is not a valid pagination cursor")
+
+// pageCursor is the keyset position encoded into an opaque cursor string
+type pageCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+}
+
+// encodeCursor encodes a keyset position as an opaque URL-safe string
+func encodeCursor(createdAt time.Time, id int) string {
+	data, _ := json.Marshal(pageCursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor decodes an opaque cursor string produced by encodeCursor
+func decodeCursor(cursor string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// normalizeLimit applies the default and maximum page size
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return limit
+}
@@ -2,7 +2,9 @@ package port
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
 )
@@ -10,16 +12,160 @@ import (
 // UserRepository is a port for user repository
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
+	// Upsert inserts the user, or updates name and created_at of the user with
+	// the same email; user.ID is set either way
+	Upsert(ctx context.Context, user *entities.User) error
 	FindByID(ctx context.Context, id int) (*entities.User, error)
-	FindAll(ctx context.Context) ([]*entities.User, error)
+	FindAll(ctx context.Context, query UserListQuery) ([]*entities.User, error)
+	Update(ctx context.Context, user *entities.User) error
+	Delete(ctx context.Context, id int) error
 	TestPanic(ctx context.Context) error // For testing panic recovery
 }
 
+// UserListQuery holds keyset pagination and filter options for listing users
+// Results are ordered by created_at DESC, id DESC
+type UserListQuery struct {
+	Limit         int
+	AfterCreated  *time.Time // keyset position: only rows strictly after (AfterCreated, AfterID)
+	AfterID       int
+	EmailPrefix   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// OrderRepository is a port for order repository
+type OrderRepository interface {
+	Create(ctx context.Context, order *entities.Order) error
+	// InsertIfAbsent inserts the order with its explicit ID unless an order with
+	// that ID exists, which is left untouched; it reports whether it inserted
+	InsertIfAbsent(ctx context.Context, order *entities.Order) (bool, error)
+	FindByID(ctx context.Context, id int) (*entities.Order, error)
+	FindAll(ctx context.Context) ([]*entities.Order, error)
+	FindByUserID(ctx context.Context, userID int) ([]*entities.Order, error)
+	// TestSlowQuery runs a deliberately slow, unindexed search for testing slow-query detection
+	// It takes at least delay and returns the number of orders whose product name contains term
+	TestSlowQuery(ctx context.Context, delay time.Duration, term string) (int, error)
+}
+
+// TxRepositories are the repositories bound to one transaction
+type TxRepositories struct {
+	Users  UserRepository
+	Orders OrderRepository
+}
+
+// UnitOfWork is a port for running writes across repositories atomically
+type UnitOfWork interface {
+	// Do begins a transaction and calls fn with repositories bound to it
+	// The transaction is committed when fn returns nil and rolled back when
+	// fn returns an error or panics; fn's error is returned unchanged
+	Do(ctx context.Context, fn func(ctx context.Context, repos TxRepositories) error) error
+}
+
 // CacheRepository is a port for cache repository
+// Writes use the implementation's default TTL unless WithTTL is given
 type CacheRepository interface {
-	Set(ctx context.Context, key string, value interface{}) error
+	Set(ctx context.Context, key string, value interface{}, opts ...CacheOption) error
+	SetNX(ctx context.Context, key string, value interface{}, opts ...CacheOption) (bool, error)
+	// Get returns ErrCacheMiss when the key does not exist
 	Get(ctx context.Context, key string) (string, error)
+	// MGet returns the values of the keys that exist; missing keys are absent from the map
+	MGet(ctx context.Context, keys ...string) (map[string]string, error)
+	MSet(ctx context.Context, values map[string]interface{}, opts ...CacheOption) error
 	Delete(ctx context.Context, key string) error
+	// Expire reports false when the key does not exist
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// TTL returns the remaining TTL, or NoExpiry for a key without one
+	// It returns ErrCacheMiss when the key does not exist
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// DeletePattern deletes keys matching a glob pattern such as "user:*"
+	DeletePattern(ctx context.Context, pattern string) (int64, error)
+	// InvalidateTag deletes every key written with WithTags(tag)
+	InvalidateTag(ctx context.Context, tag string) (int64, error)
+}
+
+// Cache errors let callers tell a cold cache apart from an outage
+// Implementations wrap the underlying error so errors.Is works on these sentinels
+var (
+	// ErrCacheMiss is returned when the key does not exist
+	ErrCacheMiss = errors.New("cache miss")
+	// ErrCacheTimeout is returned when the cache did not answer in time
+	ErrCacheTimeout = errors.New("cache timeout")
+	// ErrCacheUnavailable is returned when the cache cannot be reached
+	ErrCacheUnavailable = errors.New("cache unavailable")
+)
+
+// NoExpiry is returned by CacheRepository.TTL for keys that never expire
+const NoExpiry time.Duration = -1
+
+// CacheOptions holds per-call options for cache writes
+type CacheOptions struct {
+	TTL  time.Duration // 0 means the repository default
+	Tags []string
+}
+
+// CacheOption configures a single cache write
+type CacheOption func(*CacheOptions)
+
+// WithTTL overrides the default TTL for a single write
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.TTL = ttl
+	}
+}
+
+// WithTags associates the written keys with tags for InvalidateTag
+func WithTags(tags ...string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// ApplyCacheOptions resolves options into CacheOptions
+func ApplyCacheOptions(opts ...CacheOption) CacheOptions {
+	var o CacheOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Metrics is a port for emitting metrics (DogStatsD in production)
+// Implementations must be safe for concurrent use and must never fail the caller
+type Metrics interface {
+	Incr(name string, tags ...string)
+	Count(name string, value int64, tags ...string)
+	Gauge(name string, value float64, tags ...string)
+	Histogram(name string, value float64, tags ...string)
+	Timing(name string, value time.Duration, tags ...string)
+}
+
+// QueryStatsReader is a port for reading per-fingerprint SQL statistics
+type QueryStatsReader interface {
+	Snapshot() []QueryStat
+}
+
+// QueryStat summarizes the executions of one normalized SQL statement
+// Count, Errors and TotalMs are totals since start; percentiles and RowsAvg
+// cover the last Samples executions (RowsAvg is -1 when unknown)
+type QueryStat struct {
+	Fingerprint string  `json:"fingerprint"`
+	Query       string  `json:"query"`
+	Count       int64   `json:"count"`
+	Errors      int64   `json:"errors"`
+	TotalMs     float64 `json:"total_ms"`
+	P50Ms       float64 `json:"p50_ms"`
+	P95Ms       float64 `json:"p95_ms"`
+	P99Ms       float64 `json:"p99_ms"`
+	RowsAvg     float64 `json:"rows_avg"`
+	Samples     int     `json:"samples"`
+}
+
+// HealthChecker is a port for a dependency check used by the readiness probe
+// Check must honor ctx cancellation and apply its own timeout
+type HealthChecker interface {
+	Name() string
+	Critical() bool // a failing critical dependency makes the service not ready
+	Check(ctx context.Context) error
 }
 
 // Logger is a port for logger
@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// fixtureEpoch is the created_at of fixtures that do not set one
+// A fixed value keeps repeated seeding idempotent
+var fixtureEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Fixtures is a set of users and orders to seed, as read from YAML or JSON
+type Fixtures struct {
+	Users  []UserFixture  `yaml:"users" json:"users"`
+	Orders []OrderFixture `yaml:"orders" json:"orders"`
+}
+
+// UserFixture is a seeded user; email is its natural key for upserts
+type UserFixture struct {
+	Name      string     `yaml:"name" json:"name"`
+	Email     string     `yaml:"email" json:"email"`
+	CreatedAt *time.Time `yaml:"created_at" json:"created_at"`
+}
+
+// OrderFixture is a seeded order; its explicit ID is the key, and an existing
+// order with that ID is skipped rather than overwritten
+// The owner is given by UserEmail (a user in the same or an earlier set) or UserID
+type OrderFixture struct {
+	ID          int                  `yaml:"id" json:"id"`
+	UserEmail   string               `yaml:"user_email" json:"user_email"`
+	UserID      int                  `yaml:"user_id" json:"user_id"`
+	ProductName string               `yaml:"product_name" json:"product_name"`
+	Amount      float64              `yaml:"amount" json:"amount"`
+	Status      entities.OrderStatus `yaml:"status" json:"status"`
+	CreatedAt   *time.Time           `yaml:"created_at" json:"created_at"`
+}
+
+// SeedResult counts what a seed run wrote
+type SeedResult struct {
+	Users  int
+	Orders int
+	// OrdersSkipped counts order fixtures whose ID was already taken
+	OrdersSkipped int
+}
+
+// SeedUseCase loads fixtures through the repositories, so seeded data takes
+// the same path (validation of constraints, SQL logging, tracing) as API writes
+type SeedUseCase struct {
+	Logger port.Logger
+	RUser  port.UserRepository
+	ROrder port.OrderRepository
+	// userIDs maps seeded emails to IDs so later sets can reference them
+	userIDs map[string]int
+}
+
+// Seed upserts the users, then inserts the orders of a fixture set that are not
+// there yet; orders already present, seeded or not, are never modified
+// Running it again with the same fixtures leaves the data unchanged
+func (uc *SeedUseCase) Seed(ctx context.Context, fixtures Fixtures) (SeedResult, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.seed")
+	defer span.Finish()
+
+	if uc.userIDs == nil {
+		uc.userIDs = make(map[string]int)
+	}
+
+	var result SeedResult
+
+	for i, f := range fixtures.Users {
+		if f.Name == "" || f.Email == "" {
+			return result, fmt.Errorf("user fixture %d: name and email are required", i)
+		}
+		user := &entities.User{
+			Name:      f.Name,
+			Email:     f.Email,
+			CreatedAt: timeOrEpoch(f.CreatedAt),
+		}
+		if err := uc.RUser.Upsert(ctx, user); err != nil {
+			return result, fmt.Errorf("failed to seed user %s: %w", f.Email, err)
+		}
+		uc.userIDs[f.Email] = user.ID
+		result.Users++
+	}
+
+	for i, f := range fixtures.Orders {
+		if f.ID <= 0 {
+			return result, fmt.Errorf("order fixture %d: a positive id is required for idempotent seeding", i)
+		}
+		userID := f.UserID
+		if f.UserEmail != "" {
+			id, ok := uc.userIDs[f.UserEmail]
+			if !ok {
+				return result, fmt.Errorf("order fixture %d: unknown user_email %q", f.ID, f.UserEmail)
+			}
+			userID = id
+		}
+		status := f.Status
+		if status == "" {
+			status = entities.OrderStatusPending
+		}
+		order := &entities.Order{
+			ID:          f.ID,
+			UserID:      userID,
+			ProductName: f.ProductName,
+			Amount:      f.Amount,
+			Status:      status,
+			CreatedAt:   timeOrEpoch(f.CreatedAt),
+		}
+		inserted, err := uc.ROrder.InsertIfAbsent(ctx, order)
+		if err != nil {
+			return result, fmt.Errorf("failed to seed order %d: %w", f.ID, err)
+		}
+		if !inserted {
+			result.OrdersSkipped++
+			continue
+		}
+		result.Orders++
+	}
+
+	span.SetTag("seed.users", result.Users)
+	span.SetTag("seed.orders", result.Orders)
+	span.SetTag("seed.orders_skipped", result.OrdersSkipped)
+
+	logging.LogWithTrace(ctx, uc.Logger, "usecase", "Fixtures seeded", map[string]any{
+		"seed.users":          result.Users,
+		"seed.orders":         result.Orders,
+		"seed.orders_skipped": result.OrdersSkipped,
+	})
+
+	return result, nil
+}
+
+// timeOrEpoch returns t, or fixtureEpoch when t is unset
+func timeOrEpoch(t *time.Time) time.Time {
+	if t == nil {
+		return fixtureEpoch
+	}
+	return *t
+}
+
+// GenerateOptions configures synthetic fixtures
+type GenerateOptions struct {
+	Users  int
+	Orders int
+	// Seed makes the output reproducible: the same options give the same fixtures
+	Seed uint64
+	// FirstOrderID numbers generated orders; keep it clear of real order IDs
+	FirstOrderID int
+}
+
+// Word lists for synthetic data
+var (
+	seedFirstNames = []string{"Alice", "Bob", "Charlie", "Diana", "Ethan", "Fiona", "George", "Hana", "Ivan", "Julia", "Kenji", "Laura", "Mika", "Noah", "Olivia", "Yuki"}
+	seedLastNames  = []string{"Johnson", "Smith", "Brown", "Prince", "Hunt", "Tanaka", "Suzuki", "Garcia", "Miller", "Davis", "Sato", "Wilson"}
+	seedProducts   = []string{"Laptop", "Mouse", "Keyboard", "Monitor", "Headphones", "Webcam", "Microphone", "Desk Lamp", "USB Hub", "Docking Station"}
+	seedStatuses   = []entities.OrderStatus{entities.OrderStatusCompleted, entities.OrderStatusCompleted, entities.OrderStatusPending, entities.OrderStatusCancelled}
+)
+
+// GenerateFixtures builds deterministic synthetic users and orders
+// Users get emails seed-user-000001@example.com, ... and created_at spread over
+// the year after fixtureEpoch; orders are assigned to random generated users
+func GenerateFixtures(opts GenerateOptions) Fixtures {
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed))
+	year := int64(365 * 24 * time.Hour / time.Second)
+
+	fixtures := Fixtures{
+		Users:  make([]UserFixture, 0, opts.Users),
+		Orders: make([]OrderFixture, 0, opts.Orders),
+	}
+
+	for i := 1; i <= opts.Users; i++ {
+		createdAt := fixtureEpoch.Add(time.Duration(rng.Int64N(year)) * time.Second)
+		fixtures.Users = append(fixtures.Users, UserFixture{
+			Name:      seedFirstNames[rng.IntN(len(seedFirstNames))] + " " + seedLastNames[rng.IntN(len(seedLastNames))],
+			Email:     fmt.Sprintf("seed-user-%06d@example.com", i),
+			CreatedAt: &createdAt,
+		})
+	}
+
+	if opts.Users == 0 {
+		return fixtures
+	}
+
+	for i := 0; i < opts.Orders; i++ {
+		owner := fixtures.Users[rng.IntN(len(fixtures.Users))]
+		createdAt := owner.CreatedAt.Add(time.Duration(rng.Int64N(30*24*3600)) * time.Second)
+		fixtures.Orders = append(fixtures.Orders, OrderFixture{
+			ID:          opts.FirstOrderID + i,
+			UserEmail:   owner.Email,
+			ProductName: seedProducts[rng.IntN(len(seedProducts))],
+			Amount:      math.Round((5+rng.Float64()*1495)*100) / 100,
+			Status:      seedStatuses[rng.IntN(len(seedStatuses))],
+			CreatedAt:   &createdAt,
+		})
+	}
+
+	return fixtures
+}
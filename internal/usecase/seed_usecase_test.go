@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/memory"
+)
+
+func TestSeedUseCaseSeedTwiceLeavesOtherOrdersUntouched(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	orders := memory.NewOrderRepository(store)
+
+	// An existing user whose order takes ID 1, which the fixtures also use
+	owner := &entities.User{Name: "Zoe", Email: "zoe@example.com"}
+	if err := users.Create(ctx, owner); err != nil {
+		t.Fatal(err)
+	}
+	existing := &entities.Order{UserID: owner.ID, ProductName: "Piano", Amount: 4200, Status: entities.OrderStatusCompleted, CreatedAt: time.Now()}
+	if err := orders.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	want, err := orders.FindByID(ctx, existing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := Fixtures{
+		Users: []UserFixture{{Name: "Alice", Email: "alice@example.com"}},
+		Orders: []OrderFixture{
+			{ID: existing.ID, UserEmail: "alice@example.com", ProductName: "Laptop", Amount: 999.99},
+			{ID: 100, UserEmail: "alice@example.com", ProductName: "Mouse", Amount: 29.99},
+		},
+	}
+
+	tests := []struct {
+		name string
+		want SeedResult
+	}{
+		{name: "first run", want: SeedResult{Users: 1, Orders: 1, OrdersSkipped: 1}},
+		{name: "second run", want: SeedResult{Users: 1, Orders: 0, OrdersSkipped: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &SeedUseCase{Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), RUser: users, ROrder: orders}
+			got, err := uc.Seed(ctx, fixtures)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("want %+v, got %+v", tt.want, got)
+			}
+
+			kept, err := orders.FindByID(ctx, existing.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *kept != *want {
+				t.Fatalf("want the existing order untouched %+v, got %+v", want, kept)
+			}
+
+			all, err := orders.FindAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 2 {
+				t.Fatalf("want 2 orders, got %d", len(all))
+			}
+		})
+	}
+}
@@ -2,22 +2,25 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/kanehiroyuu/datadog-tour/internal/common/apperror"
 	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
 	"github.com/kanehiroyuu/datadog-tour/internal/common/logging"
 	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/cacheaside"
 	"github.com/kanehiroyuu/datadog-tour/internal/usecase/port"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 // UserUseCase implements user business logic
 type UserUseCase struct {
-	Logger port.Logger
-	RUser  port.UserRepository
-	RCache port.CacheRepository
+	Logger    port.Logger
+	RUser     port.UserRepository
+	UoW       port.UnitOfWork
+	UserCache *cacheaside.Cache[entities.User]
+	Metrics   port.Metrics
 }
 
 // CreateUser creates a new user
@@ -36,11 +39,17 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, name, email string) (*ent
 		CreatedAt: time.Now(),
 	}
 
+	uc.Metrics.Incr("users.create")
+
 	if err := uc.RUser.Create(ctx, user); err != nil {
-		logging.LogErrorWithTrace(ctx, uc.Logger, "usecase", "Failed to create user in repository", err, nil)
+		def, _ := apperror.Resolve(err)
+		uc.Metrics.Incr("users.create.error", "error.kind:"+string(def.Kind))
+		logging.LogError(ctx, uc.Logger, "usecase", "Failed to create user in repository", err, nil)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	uc.Metrics.Incr("users.create.success")
+
 	// Add created user ID to span
 	span.SetTag("user.id", user.ID)
 
@@ -48,23 +57,91 @@ func (uc *UserUseCase) CreateUser(ctx context.Context, name, email string) (*ent
 		"user.id": user.ID,
 	})
 
-	// Cache the user
-	cacheKey := fmt.Sprintf("user:%d", user.ID)
-	userData, _ := json.Marshal(user)
-	if err := uc.RCache.Set(ctx, cacheKey, string(userData)); err != nil {
-		// Log error but don't fail the request
+	uc.cacheUser(ctx, span, user)
+
+	return user, nil
+}
+
+// CreateUserWithOrder creates a user and their first pending order atomically
+// If either insert fails, neither row is kept
+func (uc *UserUseCase) CreateUserWithOrder(ctx context.Context, name, email, productName string, amount float64) (*entities.User, *entities.Order, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.create_user_with_order")
+	defer span.Finish()
+
+	logging.LogWithTrace(ctx, uc.Logger, "usecase", "Creating user with initial order", map[string]any{
+		"user.name":          name,
+		"user.email":         email,
+		"order.product_name": productName,
+		"order.amount":       amount,
+	})
+
+	now := time.Now()
+	user := &entities.User{
+		Name:      name,
+		Email:     email,
+		CreatedAt: now,
+	}
+	order := &entities.Order{
+		ProductName: productName,
+		Amount:      amount,
+		Status:      entities.OrderStatusPending,
+		CreatedAt:   now,
+	}
+
+	uc.Metrics.Incr("users.create")
+
+	err := uc.UoW.Do(ctx, func(ctx context.Context, repos port.TxRepositories) error {
+		if err := repos.Users.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		order.UserID = user.ID
+		if err := repos.Orders.Create(ctx, order); err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		def, _ := apperror.Resolve(err)
+		uc.Metrics.Incr("users.create.error", "error.kind:"+string(def.Kind))
+		logging.LogError(ctx, uc.Logger, "usecase", "Failed to create user with initial order, transaction rolled back", err, nil)
+		return nil, nil, err
+	}
+
+	uc.Metrics.Incr("users.create.success")
+	uc.Metrics.Incr("orders.create.success")
+	uc.Metrics.Histogram("orders.amount", amount)
+
+	span.SetTag("user.id", user.ID)
+	span.SetTag("order.id", order.ID)
+
+	logging.LogWithTrace(ctx, uc.Logger, "usecase", "User and initial order created, setting cache", map[string]any{
+		"user.id":  user.ID,
+		"order.id": order.ID,
+	})
+
+	// Only committed users are cached
+	uc.cacheUser(ctx, span, user)
+
+	return user, order, nil
+}
+
+// cacheUser writes a newly created user through to the cache
+// Writing through also replaces a negative entry left by an earlier lookup of this ID
+// Failure is logged but does not fail the request
+func (uc *UserUseCase) cacheUser(ctx context.Context, span tracer.Span, user *entities.User) {
+	cacheKey := uc.UserCache.Key(user.ID)
+	if err := uc.UserCache.Set(ctx, cacheKey, user); err != nil {
 		span.SetTag("cache.set", false)
 		logging.LogErrorWithTrace(ctx, uc.Logger, "usecase", "Failed to set user cache", err, map[string]any{
 			"cache.key": cacheKey,
 		})
-	} else {
-		span.SetTag("cache.set", true)
-		logging.LogWithTrace(ctx, uc.Logger, "usecase", "User cached successfully", map[string]any{
-			"cache.key": cacheKey,
-		})
+		return
 	}
 
-	return user, nil
+	span.SetTag("cache.set", true)
+	logging.LogWithTrace(ctx, uc.Logger, "usecase", "User cached successfully", map[string]any{
+		"cache.key": cacheKey,
+	})
 }
 
 // GetUser retrieves a user by ID with caching
@@ -80,84 +157,213 @@ func (uc *UserUseCase) GetUser(ctx context.Context, id int) (*entities.User, err
 		"user.id": id,
 	})
 
-	// Try cache first
-	cacheKey := fmt.Sprintf("user:%d", id)
-	cachedData, err := uc.RCache.Get(ctx, cacheKey)
+	// Cache-aside: concurrent misses for the same ID share one database query,
+	// and unknown IDs are negatively cached so they do not hammer MySQL
+	user, source, err := uc.UserCache.GetOrLoad(ctx, uc.UserCache.Key(id), func(ctx context.Context) (*entities.User, error) {
+		logging.LogWithTrace(ctx, logger, "usecase", "Cache miss, fetching from database", map[string]any{
+			"user.id": id,
+		})
+		// The result is cached for the whole TTL, so a lagging replica must not
+		// re-cache a user that was just updated or deleted
+		return uc.RUser.FindByID(appcontext.WithPrimaryReads(ctx), id)
+	})
 
-	if err == nil && cachedData != "" {
+	if source == cacheaside.SourceCache {
+		uc.Metrics.Incr("users.get.cache_hit")
 		span.SetTag("cache.hit", true)
 		span.SetTag("data.source", "cache")
-		var user entities.User
-		if err := json.Unmarshal([]byte(cachedData), &user); err == nil {
-			span.SetTag("user.id", user.ID)
-			logging.LogWithTrace(ctx, logger, "usecase", "User found in cache", map[string]any{
-				"user.id":   user.ID,
-				"cache.key": cacheKey,
-			})
-			return &user, nil
-		}
+	} else {
+		uc.Metrics.Incr("users.get.cache_miss")
+		span.SetTag("cache.hit", false)
+		span.SetTag("data.source", "database")
+	}
+
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to get user", err, map[string]any{
+			"user.id":     id,
+			"data.source": string(source),
+		})
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	span.SetTag("cache.hit", false)
+	logging.LogWithTrace(ctx, logger, "usecase", "User found", map[string]any{
+		"user.id":     user.ID,
+		"data.source": string(source),
+	})
+
+	return user, nil
+}
+
+// ListUsersInput holds pagination and filter options for GetAllUsers
+type ListUsersInput struct {
+	Limit         int
+	Cursor        string
+	EmailPrefix   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// GetAllUsers retrieves one page of users and the cursor for the next page
+// nextCursor is empty when there are no more results
+func (uc *UserUseCase) GetAllUsers(ctx context.Context, input ListUsersInput) ([]*entities.User, string, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.get_all_users")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	limit := normalizeLimit(input.Limit)
+
 	span.SetTag("data.source", "database")
+	span.SetTag("page.limit", limit)
+	span.SetTag("page.has_cursor", input.Cursor != "")
+	span.SetTag("filter.email_prefix", input.EmailPrefix != "")
+	span.SetTag("filter.created_after", input.CreatedAfter != nil)
+	span.SetTag("filter.created_before", input.CreatedBefore != nil)
+
+	query := port.UserListQuery{
+		// Fetch one extra row to know whether another page exists
+		Limit:         limit + 1,
+		EmailPrefix:   input.EmailPrefix,
+		CreatedAfter:  input.CreatedAfter,
+		CreatedBefore: input.CreatedBefore,
+	}
 
-	logging.LogWithTrace(ctx, logger, "usecase", "Cache miss, fetching from database", map[string]any{
+	if input.Cursor != "" {
+		cursor, err := decodeCursor(input.Cursor)
+		if err != nil {
+			logging.LogError(ctx, logger, "usecase", "Invalid pagination cursor", err, nil)
+			return nil, "", err
+		}
+		query.AfterCreated = &cursor.CreatedAt
+		query.AfterID = cursor.ID
+	}
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Fetching users page", map[string]any{
+		"page.limit":      limit,
+		"page.has_cursor": input.Cursor != "",
+	})
+
+	startTime := time.Now()
+	users, err := uc.RUser.FindAll(ctx, query)
+	uc.Metrics.Timing("users.list.duration", time.Since(startTime))
+	if err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to fetch users from repository", err, nil)
+		return nil, "", fmt.Errorf("failed to get users: %w", err)
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		last := users[len(users)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	span.SetTag("users.count", len(users))
+	span.SetTag("page.has_next", nextCursor != "")
+	span.SetTag("query.success", true)
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Users fetched successfully", map[string]any{
+		"users.count":   len(users),
+		"page.has_next": nextCursor != "",
+	})
+
+	return users, nextCursor, nil
+}
+
+// UpdateUser updates a user's name and/or email and evicts the cached copy
+// A nil name or email leaves the current value unchanged (PATCH semantics)
+func (uc *UserUseCase) UpdateUser(ctx context.Context, id int, name, email *string) (*entities.User, error) {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.update_user")
+	defer span.Finish()
+
+	logger := appcontext.GetLogger(ctx)
+
+	span.SetTag("user.id", id)
+
+	logging.LogWithTrace(ctx, logger, "usecase", "Updating user", map[string]any{
 		"user.id": id,
 	})
 
-	// Get from repository
-	user, err := uc.RUser.FindByID(ctx, id)
+	// Always read the current row from the primary, never from cache or a
+	// lagging replica, so the merged row does not overwrite a newer update
+	user, err := uc.RUser.FindByID(appcontext.WithPrimaryReads(ctx), id)
 	if err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "usecase", "Failed to get user from repository", err, map[string]any{
+		logging.LogError(ctx, logger, "usecase", "Failed to get user from repository", err, map[string]any{
 			"user.id": id,
 		})
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	logging.LogWithTrace(ctx, logger, "usecase", "User found in database, setting cache", map[string]any{
-		"user.id":   user.ID,
-		"cache.key": cacheKey,
-	})
+	if name != nil {
+		user.Name = *name
+	}
+	if email != nil {
+		user.Email = *email
+	}
 
-	// Cache the result
-	userData, _ := json.Marshal(user)
-	if err := uc.RCache.Set(ctx, cacheKey, string(userData)); err != nil {
-		span.SetTag("cache.set", false)
-		logging.LogErrorWithTrace(ctx, uc.Logger, "usecase", "Failed to set user cache", err, map[string]any{
-			"cache.key": cacheKey,
+	if err := uc.RUser.Update(ctx, user); err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to update user in repository", err, map[string]any{
+			"user.id": id,
 		})
-	} else {
-		span.SetTag("cache.set", true)
+		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
+	uc.evictUserCache(ctx, span, id)
+
+	logging.LogWithTrace(ctx, logger, "usecase", "User updated successfully", map[string]any{
+		"user.id": id,
+	})
+
 	return user, nil
 }
 
-// GetAllUsers retrieves all users
-func (uc *UserUseCase) GetAllUsers(ctx context.Context) ([]*entities.User, error) {
-	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.get_all_users")
+// DeleteUser deletes a user by ID and evicts the cached copy
+func (uc *UserUseCase) DeleteUser(ctx context.Context, id int) error {
+	span, ctx := tracer.StartSpanFromContext(ctx, "usecase.delete_user")
 	defer span.Finish()
 
 	logger := appcontext.GetLogger(ctx)
 
-	span.SetTag("data.source", "database")
+	span.SetTag("user.id", id)
 
-	logging.LogWithTrace(ctx, logger, "usecase", "Fetching all users", nil)
+	logging.LogWithTrace(ctx, logger, "usecase", "Deleting user", map[string]any{
+		"user.id": id,
+	})
 
-	users, err := uc.RUser.FindAll(ctx)
-	if err != nil {
-		logging.LogErrorWithTrace(ctx, logger, "usecase", "Failed to fetch users from repository", err, nil)
-		return nil, fmt.Errorf("failed to get users: %w", err)
+	if err := uc.RUser.Delete(ctx, id); err != nil {
+		logging.LogError(ctx, logger, "usecase", "Failed to delete user in repository", err, map[string]any{
+			"user.id": id,
+		})
+		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
-	span.SetTag("users.count", len(users))
-	span.SetTag("query.success", true)
+	uc.evictUserCache(ctx, span, id)
 
-	logging.LogWithTrace(ctx, logger, "usecase", "Users fetched successfully", map[string]any{
-		"users.count": len(users),
+	logging.LogWithTrace(ctx, logger, "usecase", "User deleted successfully", map[string]any{
+		"user.id": id,
 	})
 
-	return users, nil
+	return nil
+}
+
+// evictUserCache removes the cached user so the next read goes to the database
+// Failure is logged but does not fail the request
+func (uc *UserUseCase) evictUserCache(ctx context.Context, span tracer.Span, id int) {
+	logger := appcontext.GetLogger(ctx)
+
+	cacheKey := uc.UserCache.Key(id)
+	if err := uc.UserCache.Invalidate(ctx, cacheKey); err != nil {
+		span.SetTag("cache.evicted", false)
+		logging.LogErrorWithTrace(ctx, logger, "usecase", "Failed to evict user cache", err, map[string]any{
+			"cache.key": cacheKey,
+		})
+		return
+	}
+
+	span.SetTag("cache.evicted", true)
+	logging.LogWithTrace(ctx, logger, "usecase", "User cache evicted", map[string]any{
+		"cache.key": cacheKey,
+	})
 }
 
 // TestPanic triggers a panic in repository layer for testing recovery middleware
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	appcontext "github.com/kanehiroyuu/datadog-tour/internal/common/context"
+	"github.com/kanehiroyuu/datadog-tour/internal/domain/entities"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/memory"
+	"github.com/kanehiroyuu/datadog-tour/internal/infrastructure/metrics"
+	"github.com/kanehiroyuu/datadog-tour/internal/usecase/cacheaside"
+)
+
+// newMemoryUserUseCase returns a UserUseCase over an empty memory.Store
+func newMemoryUserUseCase(t *testing.T) (*UserUseCase, context.Context) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := memory.NewStore()
+	m := metrics.NewNoopMetrics()
+	uc := &UserUseCase{
+		Logger: logger,
+		RUser:  memory.NewUserRepository(store),
+		UoW:    memory.NewUnitOfWork(store),
+		UserCache: cacheaside.New[entities.User](memory.NewCacheRepository(time.Minute), m, logger, "user", cacheaside.Options{
+			TTL:         time.Minute,
+			NegativeTTL: time.Minute,
+			NotFound:    entities.ErrUserNotFound,
+		}),
+		Metrics: m,
+	}
+	return uc, appcontext.SetLogger(context.Background(), logger)
+}
+
+func TestUserUseCaseCreateUserWithOrder(t *testing.T) {
+	uc, ctx := newMemoryUserUseCase(t)
+
+	user, order, err := uc.CreateUserWithOrder(ctx, "Alice", "alice@example.com", "Book", 12.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.UserID != user.ID || order.Status != entities.OrderStatusPending {
+		t.Fatalf("want a pending order for user %d, got %+v", user.ID, order)
+	}
+
+	got, err := uc.GetUser(ctx, user.ID)
+	if err != nil || got.Email != "alice@example.com" {
+		t.Fatalf("want the created user, got %+v, %v", got, err)
+	}
+}
+
+func TestUserUseCaseCreateUserWithOrderRollsBack(t *testing.T) {
+	uc, ctx := newMemoryUserUseCase(t)
+
+	if _, err := uc.CreateUser(ctx, "Alice", "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := uc.CreateUserWithOrder(ctx, "Alice Again", "ALICE@example.com", "Book", 12.5)
+	if !errors.Is(err, entities.ErrDuplicateEmail) {
+		t.Fatalf("want ErrDuplicateEmail, got %v", err)
+	}
+
+	users, _, err := uc.GetAllUsers(ctx, ListUsersInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Name != "Alice" {
+		t.Fatalf("want only the first user, got %+v", users)
+	}
+}
+
+func TestUserUseCaseUpdateUserEvictsCache(t *testing.T) {
+	uc, ctx := newMemoryUserUseCase(t)
+
+	user, err := uc.CreateUser(ctx, "Alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.GetUser(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "Alicia"
+	if _, err := uc.UpdateUser(ctx, user.ID, &name, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := uc.GetUser(ctx, user.ID)
+	if err != nil || got.Name != "Alicia" || got.Email != "alice@example.com" {
+		t.Fatalf("want the updated user, got %+v, %v", got, err)
+	}
+}
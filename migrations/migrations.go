@@ -0,0 +1,13 @@
+// Package migrations embeds the versioned MySQL schema migrations
+//
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+// Statements are separated by semicolons; create new pairs with
+// `go run ./cmd/api migrate create <name>` from the repository root
+package migrations
+
+import "embed"
+
+// FS holds every migration file compiled into the binary
+//
+//go:embed *.sql
+var FS embed.FS